@@ -1,19 +1,30 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"llmbench/internal/charts"
+	"llmbench/internal/config"
 	"llmbench/internal/models"
 	"llmbench/internal/service"
+	"llmbench/internal/storage"
 	"llmbench/internal/tui"
 
+	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 )
@@ -29,15 +40,74 @@ and measure response times, success rates, and token usage.`,
 	}
 
 	// Benchmark flags
-	message     string
-	requests    int
-	concurrent  int
-	maxTokens   int
-	outputJSON  bool
-	interactive bool
-	streaming   bool
-	showCharts  bool
-	saveResults string
+	message        string
+	requests       int
+	concurrent     int
+	providerConc   map[string]int
+	maxTokens      int
+	outputJSON     bool
+	interactive    bool
+	streaming      bool
+	showCharts     bool
+	ttftPctCharts  bool
+	saveResults    string
+	quiet          bool
+	messagesFile   string
+	responseFormat string
+	jsonSchemaFile string
+	maxErrorRate   float64
+	maxP99         time.Duration
+	outputDir      string
+	temperature    float64
+	topP           float64
+	seed           int64
+	stopSequences  []string
+	frequencyPen   float64
+	presencePen    float64
+	conversation   string
+	outputFormat   string
+	skipConnTest   bool
+	score          bool
+	scoreWeights   string
+	maxDuration    time.Duration
+	stallTimeout   time.Duration
+	failFast       int
+	abortTimeouts  int
+	noKeepAlive    bool
+	prewarmConns   bool
+	strictResp     bool
+	maxParallel    int
+	compareModels  string
+	modelList      []string
+	testCasesFile  string
+	userAgent      string
+	runID          string
+	imageFiles     []string
+	providerFilter []string
+	tagFilter      []string
+	matrix         bool
+	matrixConc     []int
+	matrixTokens   []int
+	arrivalRate    float64
+	traceFile      string
+	rawBodyFile    string
+	randomize      bool
+	runs           int
+	baselineFile   string
+	summaryOnly    bool
+	maxRegression  float64
+	printRequest   bool
+	archiveFile    string
+	archiveCharts  bool
+	choices        int
+	logprobs       bool
+	topLogprobs    int64
+	influxFile     string
+	influxPerReq   bool
+	influxMeasure  string
+	influxTagNames map[string]string
+	chartImage     string
+	debugTraceFile string
 )
 
 func init() {
@@ -46,17 +116,109 @@ func init() {
 	benchmarkCmd.Flags().StringVarP(&message, "message", "m", "Hello, how are you?", "Message to send to the LLM")
 	benchmarkCmd.Flags().IntVarP(&requests, "requests", "r", 0, "Number of requests to send (overrides config)")
 	benchmarkCmd.Flags().IntVarP(&concurrent, "concurrent", "c", 0, "Number of concurrent requests (overrides config)")
+	benchmarkCmd.Flags().StringToIntVar(&providerConc, "concurrency-per-provider", nil, "Concurrency of a provider, e.g. openai=8 (repeatable; overrides --concurrent for that provider)")
 	benchmarkCmd.Flags().IntVar(&maxTokens, "max-tokens", 100, "Maximum tokens in response")
+	benchmarkCmd.Flags().StringVar(&outputFormat, "format", "", "Output format: text, json, csv, markdown, html or charts (default text)")
 	benchmarkCmd.Flags().BoolVar(&outputJSON, "json", false, "Output results in JSON format")
-	benchmarkCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Run in interactive mode with TUI")
+	benchmarkCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Run in interactive mode with TUI (default on a terminal without output flags)")
+	benchmarkCmd.Flags().BoolVar(&interactive, "tui", false, "Alias for --interactive")
 	benchmarkCmd.Flags().BoolVarP(&streaming, "streaming", "s", false, "Enable streaming mode with TTFT and throughput metrics")
+	benchmarkCmd.Flags().BoolVar(&summaryOnly, "summary-only", false, "Leave per-request results out of JSON output and saved results, keeping only the summaries")
 	benchmarkCmd.Flags().BoolVar(&showCharts, "charts", false, "Display bar charts for TTFT and throughput metrics")
-	benchmarkCmd.Flags().StringVar(&saveResults, "save", "", "Save benchmark results to YAML file (e.g., --save results.yaml)")
+	benchmarkCmd.Flags().StringVar(&sortBy, "sort", sortName, "Order of the summaries: name, latency, throughput, error-rate or tokens (best first)")
+	benchmarkCmd.Flags().BoolVar(&reverseSort, "reverse", false, "Reverse the --sort order")
+	benchmarkCmd.Flags().BoolVar(&ttftPctCharts, "ttft-percentiles", false, "Chart p50/p90/p99 TTFT as grouped bars per provider/model instead of the average")
+	benchmarkCmd.Flags().StringVar(&saveResults, "save", "", "Save benchmark results to YAML file (e.g., --save results.yaml); alone, to a timestamped file")
+	benchmarkCmd.Flags().Lookup("save").NoOptDefVal = generatedSaveName
+	benchmarkCmd.Flags().Float64Var(&temperature, "temperature", 0, "Sampling temperature (provider default when unset)")
+	benchmarkCmd.Flags().Float64Var(&topP, "top-p", 0, "Nucleus sampling top_p (provider default when unset)")
+	benchmarkCmd.Flags().Int64Var(&seed, "seed", 0, "Seed for deterministic sampling (provider default when unset)")
+	benchmarkCmd.Flags().StringArrayVar(&stopSequences, "stop", nil, "Stop sequence ending generation (repeatable; none when unset)")
+	benchmarkCmd.Flags().Float64Var(&frequencyPen, "frequency-penalty", 0, "Frequency penalty (provider default when unset)")
+	benchmarkCmd.Flags().Float64Var(&presencePen, "presence-penalty", 0, "Presence penalty (provider default when unset)")
+	benchmarkCmd.Flags().StringVar(&archiveFile, "archive", "", "Bundle the results YAML and per-request CSV into a zip (e.g., --archive run.zip)")
+	benchmarkCmd.Flags().BoolVar(&archiveCharts, "archive-charts", false, "Also add the charts, as plain text, to the --archive zip")
+	benchmarkCmd.Flags().StringVar(&chartImage, "chart-image", "", "Render the bar charts as an SVG image, for reports (e.g., --chart-image charts.svg)")
+	benchmarkCmd.Flags().IntVar(&choices, "n", 1, "Choices to generate per request (n); output tokens count across all of them (not sent when 1)")
+	benchmarkCmd.Flags().BoolVar(&logprobs, "logprobs", false, "Ask for the log probabilities of output tokens, to measure their cost (not sent when unset)")
+	benchmarkCmd.Flags().Int64Var(&topLogprobs, "top-logprobs", 0, "Most likely alternatives returned per token position, 0-20 (implies --logprobs)")
+	benchmarkCmd.Flags().StringVar(&influxFile, "influx", "", "Write the summaries in InfluxDB line protocol to a file (e.g., --influx results.lp)")
+	benchmarkCmd.Flags().BoolVar(&influxPerReq, "influx-per-request", false, "Write one --influx line per request instead of per summary")
+	benchmarkCmd.Flags().StringVar(&influxMeasure, "influx-measurement", "llmbench", "Measurement name of the --influx lines")
+	benchmarkCmd.Flags().StringToStringVar(&influxTagNames, "influx-tag-keys", nil, "Rename the provider and model tags of the --influx lines, e.g. provider=service,model=llm")
+	benchmarkCmd.Flags().StringVar(&outputDir, "output-dir", "", "Directory to save results in; without --save a timestamped filename is generated")
+	benchmarkCmd.Flags().StringVar(&messagesFile, "messages-file", "", "File with prompts to round-robin (one per line, or a YAML list)")
+	benchmarkCmd.Flags().StringVar(&conversation, "conversation", "", "YAML file with a system prompt and turns to replay as a multi-turn conversation")
+	benchmarkCmd.Flags().StringSliceVar(&imageFiles, "image", nil, "Image file (sent base64-encoded) or URL to attach to the user message; repeatable")
+	benchmarkCmd.Flags().IntVar(&runs, "runs", 1, "Repeat the whole benchmark this many times and report each metric's mean ± 95% confidence interval")
+	benchmarkCmd.Flags().BoolVar(&randomize, "randomize-prompt", false, "Append a random nonce to every prompt so that no two requests are identical, defeating response caches")
+	benchmarkCmd.Flags().StringVar(&rawBodyFile, "raw-body", "", "JSON file sent verbatim as the chat completions request body, apart from its model; usage comes from the provider")
+	benchmarkCmd.Flags().StringVar(&traceFile, "trace", "", "JSONL trace of {offset_ms, prompt} requests to replay at their original cadence")
+	benchmarkCmd.Flags().StringVar(&testCasesFile, "test-cases", "", "YAML file of prompts with expected substrings/regexes to grade responses against")
+	benchmarkCmd.Flags().StringVar(&responseFormat, "response-format", "", "Response format to request: text, json_object or json_schema")
+	benchmarkCmd.Flags().StringVar(&jsonSchemaFile, "json-schema", "", "JSON schema file for structured output (implies --response-format json_schema)")
+	benchmarkCmd.Flags().Float64Var(&maxErrorRate, "max-error-rate", -1, "Exit non-zero if any provider's error rate (percent) exceeds this value (disabled when negative)")
+	benchmarkCmd.Flags().DurationVar(&maxP99, "max-p99", 0, "Exit non-zero if any provider's p99 response time exceeds this value (e.g., 5s)")
+	benchmarkCmd.Flags().StringVar(&baselineFile, "baseline", "", "Saved results file to compare against; exit non-zero if a metric regresses past --max-regression")
+	benchmarkCmd.Flags().Float64Var(&maxRegression, "max-regression", 10, "Regression allowed against --baseline: percent for latency and throughput, points for error rate")
+	benchmarkCmd.Flags().BoolVar(&skipConnTest, "no-connection-test", false, "Skip the connection test before benchmarking; failures surface as request errors")
+	benchmarkCmd.Flags().DurationVar(&maxDuration, "max-duration", 0, "Stop starting new requests to a provider after it has run this long and report on what completed (e.g., 5m)")
+	benchmarkCmd.Flags().DurationVar(&stallTimeout, "stream-stall-timeout", 0, "Fail a streaming request when no chunk arrives for this long, as a stream_stall error (e.g., 20s)")
+	benchmarkCmd.Flags().IntVar(&maxParallel, "max-parallel-providers", 0, "Benchmark at most this many providers at once, queueing the others (all at once when 0)")
+	benchmarkCmd.Flags().BoolVar(&strictResp, "strict-responses", false, "Count responses without content or with an error in their body as failures, even with a 2xx status")
+	benchmarkCmd.Flags().IntVar(&failFast, "fail-fast", 0, "Stop sending requests to a provider/model after this many consecutive failures (disabled when 0)")
+	benchmarkCmd.Flags().IntVar(&abortTimeouts, "abort-after-timeouts", 0, "Abort the whole run after this many consecutive timeouts across all providers (disabled when 0)")
+	benchmarkCmd.Flags().StringVar(&debugTraceFile, "debug-trace", "", "Append every request and response body, status and timing to a JSONL file (records prompts and responses)")
+	benchmarkCmd.Flags().BoolVar(&noKeepAlive, "no-keepalive", false, "Open a new connection for every request, to include connection setup in every measurement")
+	benchmarkCmd.Flags().BoolVar(&prewarmConns, "prewarm-connections", false, "Resolve each provider's host and open a connection before the measured run, without sending a chat request")
+	benchmarkCmd.Flags().StringVar(&compareModels, "compare-models", "", "Compare the models of a single provider side by side (e.g., --compare-models openai)")
+	benchmarkCmd.Flags().StringSliceVar(&modelList, "models", nil, "Models to compare with --compare-models (defaults to the provider's configured models)")
+	benchmarkCmd.Flags().StringSliceVar(&providerFilter, "provider", nil, "Only benchmark provider/models matching this glob or substring; repeatable")
+	benchmarkCmd.Flags().StringSliceVar(&tagFilter, "tag", nil, "Only benchmark providers with this tag; repeatable, matching any of the tags")
+	benchmarkCmd.Flags().Float64Var(&arrivalRate, "arrival-rate", 0, "Start requests as a Poisson process with this mean rate per second per model, however many are in flight (ignores --concurrent)")
+	benchmarkCmd.Flags().BoolVar(&matrix, "matrix", false, "Sweep every combination of the concurrency and max_tokens values in the matrix config")
+	benchmarkCmd.Flags().IntSliceVar(&matrixConc, "matrix-concurrency", nil, "Concurrency values to sweep, e.g. 1,4,16 (overrides config, implies --matrix)")
+	benchmarkCmd.Flags().IntSliceVar(&matrixTokens, "matrix-max-tokens", nil, "Max tokens values to sweep, e.g. 64,512 (overrides config, implies --matrix)")
+	benchmarkCmd.Flags().BoolVar(&score, "score", false, "Rank providers with a weighted 0-100 score (weights from config, or equal)")
+	benchmarkCmd.Flags().StringVar(&scoreWeights, "score-weights", "", "Score weights, e.g. latency=2,throughput=1,error_rate=1,error_penalty=steep (implies --score)")
+	benchmarkCmd.Flags().StringVar(&userAgent, "user-agent", "", "User-Agent header to send with every request (overrides config)")
+	benchmarkCmd.Flags().StringVar(&runID, "run-id", "", "Run id sent as an X-Run-ID header and recorded in saved results (overrides config)")
+	benchmarkCmd.Flags().BoolVar(&printRequest, "print-request", false, "Print the JSON body of the first request sent to each provider/model before running")
+	benchmarkCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Suppress live progress output (implied when stdout is not a terminal)")
+
+	benchmarkCmd.Flags().MarkDeprecated("json", "use --format json instead")
+	benchmarkCmd.Flags().MarkDeprecated("charts", "use --format charts instead")
 }
 
 func runBenchmark(cmd *cobra.Command, args []string) error {
 	config := configMgr.GetBenchmarkConfig()
 
+	format, err := resolveOutputFormat(outputFormat, outputJSON, showCharts)
+	if err != nil {
+		return err
+	}
+	outputFormat = format
+	if err := takeSaveFilename(args); err != nil {
+		return err
+	}
+	if err := validateSort(); err != nil {
+		return err
+	}
+	if ttftPctCharts {
+		charts.SetTTFTPercentiles(true)
+	}
+	if summaryOnly && outputFormat == formatCSV {
+		return fmt.Errorf("--summary-only cannot be combined with the csv format, which lists requests")
+	}
+
+	weights, scoring, err := resolveScoreWeights(config)
+	if err != nil {
+		return err
+	}
+	if scoring {
+		config.Scoring = &weights
+	}
+
 	// Override config with command line flags if provided
 	if requests > 0 {
 		config.Requests = requests
@@ -64,12 +226,176 @@ func runBenchmark(cmd *cobra.Command, args []string) error {
 	if concurrent > 0 {
 		config.Concurrency = concurrent
 	}
+	if maxDuration > 0 {
+		config.MaxDuration = maxDuration.String()
+	}
+	if stallTimeout > 0 {
+		config.StreamStallTimeout = stallTimeout.String()
+	}
+	if failFast < 0 {
+		return fmt.Errorf("--fail-fast cannot be negative")
+	}
+	if failFast > 0 {
+		config.FailFast = failFast
+	}
+	if abortTimeouts < 0 {
+		return fmt.Errorf("--abort-after-timeouts cannot be negative")
+	}
+	if abortTimeouts > 0 {
+		config.AbortAfterTimeouts = abortTimeouts
+	}
+	if noKeepAlive {
+		config.DisableKeepAlives = true
+	}
+	if prewarmConns {
+		if config.DisableKeepAlives {
+			return fmt.Errorf("--prewarm-connections cannot be combined with --no-keepalive")
+		}
+		config.PrewarmConnections = true
+	}
+	if strictResp {
+		config.StrictResponses = true
+	}
+	if maxParallel < 0 {
+		return fmt.Errorf("--max-parallel-providers cannot be negative")
+	}
+	if maxParallel > 0 {
+		config.MaxParallelProviders = maxParallel
+	}
+	if arrivalRate < 0 {
+		return fmt.Errorf("--arrival-rate cannot be negative")
+	}
+	if arrivalRate > 0 {
+		config.ArrivalRate = arrivalRate
+	}
+	if userAgent != "" {
+		config.UserAgent = userAgent
+	}
+	if runID != "" {
+		config.RunID = runID
+	}
+
+	if compareModels != "" {
+		provider, err := compareProvider(config.Providers)
+		if err != nil {
+			return err
+		}
+		config.Providers = []models.Provider{provider}
+	} else if len(modelList) > 0 {
+		return fmt.Errorf("--models requires --compare-models")
+	}
+
+	if len(providerConc) > 0 {
+		config.Providers, err = overrideProviderConcurrency(config.Providers, providerConc)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(matrixConc) > 0 || len(matrixTokens) > 0 {
+		matrix = true
+		matrixConfig, err := resolveMatrix(config)
+		if err != nil {
+			return err
+		}
+		config.Matrix = matrixConfig
+	}
+	if matrix {
+		if interactive {
+			return fmt.Errorf("--matrix cannot be combined with --interactive")
+		}
+		if outputFormat != formatText && outputFormat != formatJSON {
+			return fmt.Errorf("--matrix only supports the text and json formats")
+		}
+	}
+
+	if runs < 1 {
+		return fmt.Errorf("--runs must be at least 1")
+	}
+	if runs > 1 {
+		if matrix || interactive || showCharts || baselineFile != "" || saveResults != "" || outputDir != "" {
+			return fmt.Errorf("--runs cannot be combined with --matrix, --interactive, --charts, --baseline, --save or --output-dir")
+		}
+		if outputFormat != formatText && outputFormat != formatJSON {
+			return fmt.Errorf("--runs only supports the text and json formats")
+		}
+	}
+
+	if archiveCharts && archiveFile == "" {
+		return fmt.Errorf("--archive-charts requires --archive")
+	}
+	if archiveFile != "" && (matrix || runs > 1 || interactive) {
+		return fmt.Errorf("--archive cannot be combined with --matrix, --runs or --interactive")
+	}
+	if chartImage != "" {
+		if !strings.EqualFold(filepath.Ext(chartImage), ".svg") {
+			return fmt.Errorf("--chart-image only supports .svg files")
+		}
+		if matrix || runs > 1 || interactive {
+			return fmt.Errorf("--chart-image cannot be combined with --matrix, --runs or --interactive")
+		}
+	}
+	if influxFile == "" && (influxPerReq || cmd.Flags().Changed("influx-measurement") || len(influxTagNames) > 0) {
+		return fmt.Errorf("--influx-per-request, --influx-measurement and --influx-tag-keys require --influx")
+	}
+	if influxFile != "" {
+		if matrix || runs > 1 || interactive {
+			return fmt.Errorf("--influx cannot be combined with --matrix, --runs or --interactive")
+		}
+		if influxMeasure == "" {
+			return fmt.Errorf("--influx-measurement cannot be empty")
+		}
+		for tag, renamed := range influxTagNames {
+			if !slices.Contains(influxTagKeys, tag) {
+				return fmt.Errorf("invalid --influx-tag-keys tag %q: must be one of %s", tag, strings.Join(influxTagKeys, ", "))
+			}
+			if renamed == "" {
+				return fmt.Errorf("--influx-tag-keys cannot rename the %s tag to an empty key", tag)
+			}
+		}
+	}
+
+	var baseline map[string]models.BenchmarkSummary
+	if baselineFile != "" {
+		if matrix || interactive {
+			return fmt.Errorf("--baseline cannot be combined with --matrix or --interactive")
+		}
+		if maxRegression < 0 {
+			return fmt.Errorf("--max-regression cannot be negative")
+		}
+		baseline, err = loadBaseline(baselineFile)
+		if err != nil {
+			return fmt.Errorf("failed to load baseline from %s: %w", baselineFile, err)
+		}
+	}
+
+	if len(tagFilter) > 0 {
+		config.Providers = filterProvidersByTag(config.Providers, tagFilter)
+		if len(config.Providers) == 0 {
+			return fmt.Errorf("no configured providers are tagged %s", strings.Join(tagFilter, ", "))
+		}
+	}
+	if len(providerFilter) > 0 {
+		config.Providers = filterProviders(config.Providers, providerFilter)
+		if len(config.Providers) == 0 {
+			return fmt.Errorf("no configured provider/models match --provider %s", strings.Join(providerFilter, ", "))
+		}
+	}
+
+	if debugTraceFile != "" {
+		if err := service.SetDebugTrace(debugTraceFile); err != nil {
+			return err
+		}
+		defer service.CloseDebugTrace()
+		fmt.Fprintf(os.Stderr, "⚠️  WARNING: --debug-trace records prompts and responses verbatim in %s\n", debugTraceFile)
+	}
 
 	// Create benchmark service
 	benchmarkService, err := service.NewBenchmarkService(config)
 	if err != nil {
 		return fmt.Errorf("failed to create benchmark service: %w", err)
 	}
+	defer benchmarkService.Close()
 
 	// Create benchmark request
 	benchmarkRequest := models.BenchmarkRequest{
@@ -83,200 +409,965 @@ func runBenchmark(cmd *cobra.Command, args []string) error {
 		Stream:    streaming,
 	}
 
+	// Sampling settings are only sent when explicitly requested
+	if cmd.Flags().Changed("temperature") {
+		benchmarkRequest.Temperature = &temperature
+	}
+	if cmd.Flags().Changed("top-p") {
+		benchmarkRequest.TopP = &topP
+	}
+	if cmd.Flags().Changed("seed") {
+		benchmarkRequest.Seed = &seed
+	}
+	if len(stopSequences) > 0 {
+		benchmarkRequest.Stop = stopSequences
+	}
+	if cmd.Flags().Changed("frequency-penalty") {
+		benchmarkRequest.FrequencyPenalty = &frequencyPen
+	}
+	if cmd.Flags().Changed("presence-penalty") {
+		benchmarkRequest.PresencePenalty = &presencePen
+	}
+	if choices < 1 {
+		return fmt.Errorf("--n must be at least 1")
+	}
+	benchmarkRequest.N = choices
+	if logprobs || cmd.Flags().Changed("top-logprobs") {
+		benchmarkRequest.Logprobs = true
+	}
+	if cmd.Flags().Changed("top-logprobs") {
+		if topLogprobs < 0 || topLogprobs > 20 {
+			return fmt.Errorf("--top-logprobs must be between 0 and 20")
+		}
+		benchmarkRequest.TopLogprobs = &topLogprobs
+	}
+	benchmarkRequest.RandomizePrompt = randomize
+
+	if jsonSchemaFile != "" {
+		schema, err := loadJSONSchema(jsonSchemaFile)
+		if err != nil {
+			return fmt.Errorf("failed to load JSON schema from %s: %w", jsonSchemaFile, err)
+		}
+		benchmarkRequest.JSONSchema = schema
+		if responseFormat == "" {
+			responseFormat = models.ResponseFormatJSONSchema
+		}
+	}
+
+	switch responseFormat {
+	case "", models.ResponseFormatText, models.ResponseFormatJSONObject:
+	case models.ResponseFormatJSONSchema:
+		if benchmarkRequest.JSONSchema == nil {
+			return fmt.Errorf("--response-format json_schema requires --json-schema")
+		}
+	default:
+		return fmt.Errorf("invalid response format %q: must be text, json_object or json_schema", responseFormat)
+	}
+	benchmarkRequest.ResponseFormat = responseFormat
+
+	if messagesFile != "" {
+		prompts, err := loadMessagesFile(messagesFile)
+		if err != nil {
+			return fmt.Errorf("failed to load messages from %s: %w", messagesFile, err)
+		}
+		benchmarkRequest.Prompts = prompts
+	}
+
+	if conversation != "" {
+		if messagesFile != "" {
+			return fmt.Errorf("--conversation cannot be combined with --messages-file")
+		}
+		conv, err := loadConversation(conversation)
+		if err != nil {
+			return fmt.Errorf("failed to load conversation from %s: %w", conversation, err)
+		}
+		benchmarkRequest.Messages = nil
+		if conv.System != "" {
+			benchmarkRequest.Messages = []models.ChatMessage{{Role: "system", Content: conv.System}}
+		}
+		benchmarkRequest.Conversation = conv.Turns
+	}
+
+	if testCasesFile != "" {
+		if messagesFile != "" || conversation != "" {
+			return fmt.Errorf("--test-cases cannot be combined with --messages-file or --conversation")
+		}
+		testCases, err := loadTestCases(testCasesFile)
+		if err != nil {
+			return fmt.Errorf("failed to load test cases from %s: %w", testCasesFile, err)
+		}
+		benchmarkRequest.TestCases = testCases
+	}
+
+	if traceFile != "" {
+		if messagesFile != "" || conversation != "" || testCasesFile != "" {
+			return fmt.Errorf("--trace cannot be combined with --messages-file, --conversation or --test-cases")
+		}
+		if matrix || arrivalRate > 0 {
+			return fmt.Errorf("--trace cannot be combined with --matrix or --arrival-rate")
+		}
+		trace, err := loadTrace(traceFile)
+		if err != nil {
+			return fmt.Errorf("failed to load trace from %s: %w", traceFile, err)
+		}
+		benchmarkRequest.Trace = trace
+	}
+
+	if rawBodyFile != "" {
+		if matrix {
+			return fmt.Errorf("--raw-body cannot be combined with --matrix, whose max tokens values it would ignore")
+		}
+		if err := checkRawBodyFlags(cmd); err != nil {
+			return err
+		}
+		body, err := loadRawBody(rawBodyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load raw body from %s: %w", rawBodyFile, err)
+		}
+		// The body carries its own messages and settings
+		benchmarkRequest.RawBody = body
+		benchmarkRequest.Messages = nil
+		benchmarkRequest.MaxTokens = 0
+	}
+
+	for _, imageFile := range imageFiles {
+		image, err := loadImage(imageFile)
+		if err != nil {
+			return fmt.Errorf("failed to load image from %s: %w", imageFile, err)
+		}
+		benchmarkRequest.Images = append(benchmarkRequest.Images, image)
+	}
+
+	// Workers beyond the number of requests would sit idle, which is likely
+	// a mistake rather than the parallelism the user expected. Matrix cells
+	// set their own concurrency
+	if !matrix {
+		idle := benchmarkService.IdleWorkers(benchmarkRequest)
+		for _, name := range slices.Sorted(maps.Keys(idle)) {
+			fmt.Fprintf(os.Stderr, "⚠️  WARNING: %s concurrency %d exceeds requests %d; at most %d requests per model run at once\n", name, idle[name], config.Requests, config.Requests)
+		}
+	}
+
 	ctx := context.Background()
 
-	if interactive {
+	if matrix {
+		return runMatrixBenchmark(ctx, benchmarkService, benchmarkRequest)
+	}
+	if runs > 1 {
+		return runRepeatedBenchmark(ctx, benchmarkService, benchmarkRequest)
+	}
+
+	if interactive || defaultsToTUI(cmd) {
 		// Run interactive TUI mode
 		return runInteractiveBenchmark(ctx, benchmarkService, benchmarkRequest)
 	}
 
 	// Run in CLI mode
-	return runCLIBenchmark(ctx, benchmarkService, benchmarkRequest)
+	return runCLIBenchmark(ctx, benchmarkService, benchmarkRequest, baseline)
 }
 
 func runInteractiveBenchmark(ctx context.Context, benchmarkService *service.BenchmarkService, request models.BenchmarkRequest) error {
-	app := tui.NewApp(benchmarkService, request)
+	// Log lines on stderr would tear through the alternate screen
+	service.SetVerbose(false)
+
+	// Previous results are looked up where this run would save its own
+	resultsDir := outputDir
+	if resultsDir == "" {
+		resultsDir = "."
+	}
+
+	app := tui.NewApp(benchmarkService, request, resultsDir)
 	return app.Run()
 }
 
-func runCLIBenchmark(ctx context.Context, benchmarkService *service.BenchmarkService, request models.BenchmarkRequest) error {
-	fmt.Println("Starting benchmark...")
-	fmt.Printf("Message: %s\n", message)
-	fmt.Printf("Requests per provider: %d\n", configMgr.GetBenchmarkConfig().Requests)
-	fmt.Printf("Concurrency: %d\n", configMgr.GetBenchmarkConfig().Concurrency)
-	fmt.Println()
+func runCLIBenchmark(ctx context.Context, benchmarkService *service.BenchmarkService, request models.BenchmarkRequest, baseline map[string]models.BenchmarkSummary) error {
+	out := statusOutput()
+	fmt.Fprintln(out, "Starting benchmark...")
+	if len(request.Trace) > 0 {
+		last := request.Trace[len(request.Trace)-1].Offset() - request.Trace[0].Offset()
+		fmt.Fprintf(out, "Trace: %d requests over %v from %s\n", len(request.Trace), last, traceFile)
+	} else if len(request.Conversation) > 0 {
+		fmt.Fprintf(out, "Conversation: %d turns from %s\n", len(request.Conversation), conversation)
+	} else if len(request.TestCases) > 0 {
+		fmt.Fprintf(out, "Test cases: %d from %s\n", len(request.TestCases), testCasesFile)
+	} else if len(request.Prompts) > 0 {
+		fmt.Fprintf(out, "Messages: %d prompts from %s\n", len(request.Prompts), messagesFile)
+	} else if len(request.RawBody) > 0 {
+		fmt.Fprintf(out, "Raw body: %s\n", rawBodyFile)
+	} else {
+		fmt.Fprintf(out, "Message: %s\n", message)
+	}
+	if len(request.Trace) == 0 {
+		fmt.Fprintf(out, "Requests per provider: %d\n", benchmarkService.GetConfig().Requests)
+	}
+	if len(request.Trace) > 0 {
+		fmt.Fprintf(out, "Cadence: replayed from the trace\n")
+	} else if rate := benchmarkService.GetConfig().ArrivalRate; rate > 0 {
+		fmt.Fprintf(out, "Arrival rate: %g requests/sec (Poisson)\n", rate)
+	} else {
+		fmt.Fprintf(out, "Concurrency: %d\n", benchmarkService.GetConfig().Concurrency)
+	}
+	fmt.Fprintln(out)
+
+	// Test connections first, unless skipped to avoid paying for extra calls
+	if !skipConnTest {
+		printConnectionTest(ctx, benchmarkService)
+	}
+
+	if printRequest {
+		printEffectiveRequests(benchmarkService, request)
+	}
+
+	// Run benchmark
+	fmt.Fprintln(out, "Running benchmark...")
+
+	eta := service.NewETATracker()
+	progressCallback := func(provider string, completed, total int) {
+		eta.Record(provider, completed)
+		if completed == total {
+			fmt.Fprintf(out, "\r%s: %d/%d completed ✅%s\n", provider, completed, total, strings.Repeat(" ", 16))
+			return
+		}
+		// Padded so a shorter ETA fully overwrites a longer one
+		fmt.Fprintf(out, "\r%s: %d/%d completed (%-16s", provider, completed, total, eta.Describe(provider, total)+")")
+	}
+
+	// Carriage-return progress lines turn into garbage in CI logs, so only
+	// print a final per-provider summary when quiet or not on a terminal
+	liveProgress := !quiet && isTerminal(out)
+	if !liveProgress {
+		progressCallback = nil
+	}
+
+	// A run aborted part way still reports what it completed, and fails
+	// once that is out
+	run, runErr := benchmarkService.RunBenchmark(ctx, request, progressCallback)
+	if run == nil {
+		return fmt.Errorf("benchmark failed: %w", runErr)
+	}
+	if runErr != nil {
+		runErr = fmt.Errorf("benchmark aborted: %w", runErr)
+	}
+	results := run.Results
+
+	if !liveProgress {
+		printCompletionSummary(results)
+	}
+
+	fmt.Fprintln(out, "\nGenerating summary...")
+	summaries := benchmarkService.GenerateSummary(run)
+
+	// Save results to YAML file if requested
+	if savePath := resolveSavePath(time.Now()); savePath != "" {
+		if err := saveBenchmarkResults(benchmarkService.GetConfig(), request, summaries, results, savePath); err != nil {
+			return fmt.Errorf("failed to save results: %w", err)
+		}
+		fmt.Fprintf(out, "✅ Results saved to %s\n", savePath)
+	}
+	if archiveFile != "" {
+		resultsFile := runResultsFile(benchmarkService.GetConfig(), request, summaries, results)
+		if err := writeArchive(archiveFile, resultsFile, results, archiveCharts); err != nil {
+			return fmt.Errorf("failed to write archive: %w", err)
+		}
+		fmt.Fprintf(out, "✅ Archive written to %s\n", archiveFile)
+	}
+	if chartImage != "" {
+		if err := os.WriteFile(chartImage, []byte(charts.SVG(summaries)), 0644); err != nil {
+			return fmt.Errorf("failed to write chart image: %w", err)
+		}
+		fmt.Fprintf(out, "✅ Chart image written to %s\n", chartImage)
+	}
+	if influxFile != "" {
+		if err := writeInflux(influxFile, influxMeasure, influxTagNames, time.Now(), summaries, results, influxPerReq); err != nil {
+			return fmt.Errorf("failed to write line protocol: %w", err)
+		}
+		fmt.Fprintf(out, "✅ Line protocol written to %s\n", influxFile)
+	}
+
+	var err error
+	if compareModels != "" && outputFormat == formatText {
+		err = outputModelComparison(compareModels, summaries)
+	} else {
+		err = writeResults(outputFormat, summaries, results)
+	}
+	if err != nil {
+		return err
+	}
+
+	slaResults := benchmarkService.CheckSLAs(summaries)
+	var baselineResults []service.BaselineResult
+	if baseline != nil {
+		baselineResults = service.CompareBaseline(summaries, baseline, maxRegression)
+	}
+	if outputFormat == formatText {
+		printSLAResults(slaResults)
+		printBaselineResults(baselineResults)
+	}
+
+	return errors.Join(runErr, checkThresholds(summaries), slaError(slaResults), baselineError(baselineResults))
+}
+
+// runMatrixBenchmark runs one benchmark per combination of the matrix
+// values and reports them as a grid per provider/model
+func runMatrixBenchmark(ctx context.Context, benchmarkService *service.BenchmarkService, request models.BenchmarkRequest) error {
+	out := statusOutput()
+	fmt.Fprintln(out, "Starting matrix benchmark...")
+	fmt.Fprintf(out, "Requests per provider and combination: %d\n", benchmarkService.GetConfig().Requests)
+	fmt.Fprintln(out)
+
+	if !skipConnTest {
+		printConnectionTest(ctx, benchmarkService)
+	}
+
+	matrixResults, err := benchmarkService.RunMatrix(ctx, request, func(cell models.MatrixCell, index, total int) {
+		fmt.Fprintf(out, "▶️  [%d/%d] concurrency %d, max tokens %d\n", index+1, total, cell.Concurrency, cell.MaxTokens)
+	})
+	if err != nil {
+		return fmt.Errorf("matrix benchmark failed: %w", err)
+	}
+
+	if savePath := resolveSavePath(time.Now()); savePath != "" {
+		resultsFile := newResultsFile(benchmarkService.GetConfig(), request)
+		resultsFile.Matrix = matrixResults
+		if summaryOnly {
+			resultsFile.Matrix = matrixResults.WithoutResults()
+		}
+		if err := storage.SaveResults(savePath, resultsFile); err != nil {
+			return fmt.Errorf("failed to save results: %w", err)
+		}
+		fmt.Fprintf(out, "✅ Results saved to %s\n", savePath)
+	}
+
+	return writeMatrixResults(outputFormat, matrixResults)
+}
+
+// runRepeatedBenchmark runs the whole benchmark several times and reports
+// every metric's mean and 95% confidence interval over the runs
+func runRepeatedBenchmark(ctx context.Context, benchmarkService *service.BenchmarkService, request models.BenchmarkRequest) error {
+	out := statusOutput()
+	fmt.Fprintf(out, "Starting benchmark, %d runs...\n", runs)
+	fmt.Fprintf(out, "Requests per provider and run: %d\n", benchmarkService.GetConfig().Requests)
+	fmt.Fprintln(out)
+
+	if !skipConnTest {
+		printConnectionTest(ctx, benchmarkService)
+	}
+	if printRequest {
+		printEffectiveRequests(benchmarkService, request)
+	}
+
+	// Thresholds and SLAs gate every run, so that a single bad run fails
+	// the whole command as it would on its own
+	var summaries []map[string]models.BenchmarkSummary
+	var failures []error
+	for i := range runs {
+		fmt.Fprintf(out, "▶️  [%d/%d] running benchmark\n", i+1, runs)
+		run, err := benchmarkService.RunBenchmark(ctx, request, nil)
+		if err != nil {
+			return fmt.Errorf("benchmark run %d failed: %w", i+1, err)
+		}
+		runSummaries := benchmarkService.GenerateSummary(run)
+		summaries = append(summaries, runSummaries)
+		if err := errors.Join(checkThresholds(runSummaries), slaError(benchmarkService.CheckSLAs(runSummaries))); err != nil {
+			failures = append(failures, fmt.Errorf("run %d: %w", i+1, err))
+		}
+	}
+
+	if err := writeRepeatedResults(outputFormat, runs, service.AggregateRuns(summaries)); err != nil {
+		return err
+	}
+	return errors.Join(failures...)
+}
+
+// statusOutput returns where a run's preamble, progress and notices are
+// printed: stdout along the text and charts formats, stderr along the
+// others, so that their output can be redirected to a file intact
+func statusOutput() *os.File {
+	if outputFormat == formatText || outputFormat == formatCharts {
+		return os.Stdout
+	}
+	return os.Stderr
+}
 
-	// Test connections first
-	fmt.Println("Testing connections...")
+// printConnectionTest tests every provider's connection and prints the outcome
+func printConnectionTest(ctx context.Context, benchmarkService *service.BenchmarkService) {
+	out := statusOutput()
+	fmt.Fprintln(out, "Testing connections...")
 	connectionResults := benchmarkService.TestConnections(ctx)
 
 	failedConnections := 0
-	for provider, err := range connectionResults {
+	for _, provider := range slices.Sorted(maps.Keys(connectionResults)) {
+		err := connectionResults[provider]
 		if err != nil {
-			fmt.Printf("❌ %s: %v\n", provider, err)
+			fmt.Fprintf(out, "❌ %s: %v\n", provider, err)
 			failedConnections++
 		} else {
-			fmt.Printf("✅ %s: Connected\n", provider)
+			fmt.Fprintf(out, "✅ %s: Connected\n", provider)
 		}
 	}
 
 	if failedConnections > 0 {
-		fmt.Printf("\n⚠️  %d provider(s) failed connection test\n", failedConnections)
+		fmt.Fprintf(out, "\n⚠️  %d provider(s) failed connection test\n", failedConnections)
 	}
-	fmt.Println()
+	fmt.Fprintln(out)
+}
 
-	// Run benchmark
-	fmt.Println("Running benchmark...")
+// printEffectiveRequests prints the JSON body of the first request every
+// provider/model will be sent. It is printed before the benchmark starts so
+// it never tears through the progress lines
+func printEffectiveRequests(benchmarkService *service.BenchmarkService, request models.BenchmarkRequest) {
+	out := statusOutput()
+	fmt.Fprintln(out, "Effective requests:")
+	headerStyle := lipgloss.NewStyle().Bold(true)
+	for _, effective := range benchmarkService.EffectiveRequests(request) {
+		fmt.Fprintln(out, headerStyle.Render(effective.Key + ":"))
+		if effective.Err != nil {
+			fmt.Fprintf(out, "❌ %v\n", effective.Err)
+			continue
+		}
+		var body bytes.Buffer
+		if err := json.Indent(&body, effective.Body, "", "  "); err != nil {
+			body.Reset()
+			body.Write(effective.Body)
+		}
+		fmt.Fprintln(out, body.String())
+	}
+	fmt.Fprintln(out)
+}
 
-	progressCallback := func(provider string, completed, total int) {
-		fmt.Printf("\r%s: %d/%d completed", provider, completed, total)
-		if completed == total {
-			fmt.Printf(" ✅\n")
+// slaError returns an error listing every provider/model that missed its SLA
+func slaError(results []service.SLAResult) error {
+	var failures []string
+	for _, result := range results {
+		for _, violation := range result.Violations {
+			failures = append(failures, fmt.Sprintf("%s: %s", result.Key, violation))
+		}
+	}
+
+	if len(failures) == 0 {
+		return nil
+	}
+	sort.Strings(failures)
+	return fmt.Errorf("SLA not met:\n  %s", strings.Join(failures, "\n  "))
+}
+
+// baselineError returns an error listing every metric that regressed
+// against the baseline past --max-regression
+func baselineError(results []service.BaselineResult) error {
+	var failures []string
+	for _, result := range results {
+		for _, change := range result.Regressions() {
+			failures = append(failures, fmt.Sprintf("%s: %s %s -> %s (%+.1f%s)", result.Key, change.Metric, change.Baseline, change.Current, change.Change, change.Unit))
 		}
 	}
 
-	results, err := benchmarkService.RunBenchmark(ctx, request, progressCallback)
+	if len(failures) == 0 {
+		return nil
+	}
+	return fmt.Errorf("regressions against baseline %s:\n  %s", baselineFile, strings.Join(failures, "\n  "))
+}
+
+// loadBaseline loads the summaries of a saved results file
+func loadBaseline(filename string) (map[string]models.BenchmarkSummary, error) {
+	resultsFile, err := storage.LoadResults(filename)
 	if err != nil {
-		return fmt.Errorf("benchmark failed: %w", err)
+		return nil, err
+	}
+	if resultsFile.Matrix != nil {
+		return nil, fmt.Errorf("matrix results cannot be used as a baseline")
+	}
+	if len(resultsFile.Summaries) == 0 {
+		return nil, fmt.Errorf("no summaries found")
+	}
+	return resultsFile.Summaries, nil
+}
+
+// checkThresholds returns an error listing every provider that crossed the
+// --max-error-rate or --max-p99 thresholds, if they were set
+func checkThresholds(summaries map[string]models.BenchmarkSummary) error {
+	checkErrorRate := maxErrorRate >= 0
+	checkP99 := maxP99 > 0
+	if !checkErrorRate && !checkP99 {
+		return nil
 	}
 
-	fmt.Println("\nGenerating summary...")
-	summaries := benchmarkService.GenerateSummary(results)
+	keys := make([]string, 0, len(summaries))
+	for key := range summaries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
 
-	// Save results to YAML file if requested
-	if saveResults != "" {
-		if err := saveBenchmarkResults(summaries, results, saveResults); err != nil {
-			return fmt.Errorf("failed to save results: %w", err)
+	var failures []string
+	for _, key := range keys {
+		summary := summaries[key]
+		if checkErrorRate && summary.ErrorRate > maxErrorRate {
+			failures = append(failures, fmt.Sprintf("%s: error rate %.2f%% exceeds %.2f%%", key, summary.ErrorRate, maxErrorRate))
+		}
+		if checkP99 && summary.P99ResponseTime > maxP99 {
+			failures = append(failures, fmt.Sprintf("%s: p99 response time %v exceeds %v", key, summary.P99ResponseTime, maxP99))
 		}
-		fmt.Printf("✅ Results saved to %s\n", saveResults)
 	}
 
-	if outputJSON {
-		return outputJSONResults(summaries, results)
+	if len(failures) > 0 {
+		return fmt.Errorf("benchmark thresholds exceeded:\n  %s", strings.Join(failures, "\n  "))
 	}
 
-	return outputTextResults(summaries)
+	return nil
 }
 
-func outputJSONResults(summaries map[string]models.BenchmarkSummary, results map[string][]models.BenchmarkResult) error {
-	output := struct {
-		Summaries map[string]models.BenchmarkSummary  `json:"summaries"`
-		Results   map[string][]models.BenchmarkResult `json:"results"`
-	}{
-		Summaries: summaries,
-		Results:   results,
+// compareProvider returns the provider pinned by --compare-models, with its
+// models replaced by --models when given
+func compareProvider(providers []models.Provider) (models.Provider, error) {
+	for _, provider := range providers {
+		if provider.Name != compareModels {
+			continue
+		}
+		if len(modelList) > 0 {
+			provider.Models = modelList
+		}
+		if len(provider.Models) < 2 {
+			return provider, fmt.Errorf("--compare-models needs at least two models for provider %s", provider.Name)
+		}
+		return provider, nil
 	}
 
-	encoder := json.NewEncoder(os.Stdout)
-	encoder.SetIndent("", "  ")
-	return encoder.Encode(output)
+	return models.Provider{}, fmt.Errorf("provider %s not found in configuration", compareModels)
 }
 
-func outputTextResults(summaries map[string]models.BenchmarkSummary) error {
-	// If charts are requested, show only charts
-	if showCharts {
-		fmt.Println("\n" + strings.Repeat("=", 80))
-		fmt.Println("BENCHMARK CHARTS")
-		fmt.Println(strings.Repeat("=", 80))
-		
-		// Create chart generator with appropriate dimensions
-		chartGen := charts.NewChartGenerator(60, 15)
-		chartsOutput := chartGen.GenerateAllCharts(summaries)
-		fmt.Print(chartsOutput)
-		fmt.Println(strings.Repeat("=", 80))
-		return nil
+// resolveScoreWeights returns the weights to score providers with, and
+// whether scoring was requested through the flags or the config
+func resolveScoreWeights(benchmarkConfig models.BenchmarkConfig) (models.ScoreWeights, bool, error) {
+	if scoreWeights != "" {
+		weights, err := parseScoreWeights(scoreWeights)
+		if err != nil {
+			return weights, false, fmt.Errorf("invalid --score-weights: %w", err)
+		}
+		return weights, true, nil
 	}
 
-	// Otherwise, show text summary
-	fmt.Println("\n" + strings.Repeat("=", 80))
-	fmt.Println("BENCHMARK RESULTS")
-	fmt.Println(strings.Repeat("=", 80))
+	if benchmarkConfig.Scoring != nil {
+		return *benchmarkConfig.Scoring, true, nil
+	}
 
-	for _, summary := range summaries {
-		// Display provider and model name clearly
-		if summary.ModelName != "" {
-			fmt.Printf("\n📊 %s - %s\n", strings.ToUpper(summary.Provider), summary.ModelName)
-		} else {
-			fmt.Printf("\n📊 %s\n", strings.ToUpper(summary.Provider))
-		}
-		fmt.Println(strings.Repeat("-", 50))
-		fmt.Printf("Total Requests:     %d\n", summary.TotalRequests)
-		fmt.Printf("Successful:         %d\n", summary.SuccessfulReqs)
-		fmt.Printf("Failed:             %d\n", summary.FailedRequests)
-		fmt.Printf("Error Rate:         %.2f%%\n", summary.ErrorRate)
-		fmt.Printf("Avg Response Time:  %v\n", summary.AvgResponseTime)
-		fmt.Printf("Min Response Time:  %v\n", summary.MinResponseTime)
-		fmt.Printf("Max Response Time:  %v\n", summary.MaxResponseTime)
-		fmt.Printf("Total Tokens:       %d\n", summary.TotalTokens)
-		
-		// Display streaming metrics if available
-		if summary.IsStreaming {
-			fmt.Println("\n🚀 STREAMING METRICS")
-			fmt.Println(strings.Repeat("-", 20))
-			fmt.Printf("Avg Time to First Token: %v\n", summary.AvgTimeToFirstToken)
-			fmt.Printf("Min Time to First Token: %v\n", summary.MinTimeToFirstToken)
-			fmt.Printf("Max Time to First Token: %v\n", summary.MaxTimeToFirstToken)
-			fmt.Printf("Avg Token Throughput:    %.2f tokens/sec\n", summary.AvgTokenThroughput)
-			fmt.Printf("Min Token Throughput:    %.2f tokens/sec\n", summary.MinTokenThroughput)
-			fmt.Printf("Max Token Throughput:    %.2f tokens/sec\n", summary.MaxTokenThroughput)
-		}
-	}
-
-	fmt.Println("\n" + strings.Repeat("=", 80))
+	return models.DefaultScoreWeights(), score, nil
+}
+
+// resolveMatrix returns the matrix config with the values given through the
+// flags replacing those of the config
+func resolveMatrix(benchmarkConfig models.BenchmarkConfig) (*models.MatrixConfig, error) {
+	matrixConfig := models.MatrixConfig{}
+	if benchmarkConfig.Matrix != nil {
+		matrixConfig = *benchmarkConfig.Matrix
+	}
+	if len(matrixConc) > 0 {
+		matrixConfig.Concurrency = matrixConc
+	}
+	if len(matrixTokens) > 0 {
+		matrixConfig.MaxTokens = matrixTokens
+	}
+
+	if err := config.ValidateMatrix(matrixConfig); err != nil {
+		return nil, fmt.Errorf("invalid matrix: %w", err)
+	}
+	return &matrixConfig, nil
+}
+
+// parseScoreWeights parses comma-separated metric=weight pairs, plus an
+// optional error_penalty=curve; metrics that are left out get a weight of
+// 0, unless only the curve is given
+func parseScoreWeights(value string) (models.ScoreWeights, error) {
+	var weights models.ScoreWeights
+	weighted := false
+	for _, pair := range strings.Split(value, ",") {
+		name, raw, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return weights, fmt.Errorf("expected metric=weight, got %q", pair)
+		}
+		if name == "error_penalty" {
+			weights.ErrorPenalty = raw
+			continue
+		}
+		weight, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return weights, fmt.Errorf("invalid weight for %s: %w", name, err)
+		}
+		weighted = true
+		switch name {
+		case "latency":
+			weights.Latency = weight
+		case "throughput":
+			weights.Throughput = weight
+		case "error_rate":
+			weights.ErrorRate = weight
+		default:
+			return weights, fmt.Errorf("unknown metric %q: must be latency, throughput, error_rate or error_penalty", name)
+		}
+	}
+
+	// A curve alone keeps the default weights
+	if !weighted {
+		penalty := weights.ErrorPenalty
+		weights = models.DefaultScoreWeights()
+		weights.ErrorPenalty = penalty
+	}
+
+	return weights, config.ValidateScoreWeights(weights)
+}
+
+// filterProviders narrows providers down to the models whose provider/model
+// key matches one of the patterns, dropping providers left without models
+func filterProviders(providers []models.Provider, patterns []string) []models.Provider {
+	var filtered []models.Provider
+	for _, provider := range providers {
+		var providerModels []string
+		for _, model := range provider.Models {
+			if matchesProvider(provider.Name+"/"+model, patterns) {
+				providerModels = append(providerModels, model)
+			}
+		}
+		if len(providerModels) > 0 {
+			provider.Models = providerModels
+			filtered = append(filtered, provider)
+		}
+	}
+	return filtered
+}
+
+// overrideProviderConcurrency sets the concurrency of the named providers,
+// leaving the configured providers themselves untouched
+func overrideProviderConcurrency(providers []models.Provider, concurrency map[string]int) ([]models.Provider, error) {
+	providers = slices.Clone(providers)
+	for name, value := range concurrency {
+		if value <= 0 {
+			return nil, fmt.Errorf("--concurrency-per-provider %s must be greater than 0", name)
+		}
+		i := slices.IndexFunc(providers, func(p models.Provider) bool { return p.Name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("--concurrency-per-provider: unknown provider %s", name)
+		}
+		providers[i].Concurrency = value
+	}
+	return providers, nil
+}
+
+// filterProvidersByTag narrows providers down to those with any of the tags
+func filterProvidersByTag(providers []models.Provider, tags []string) []models.Provider {
+	var filtered []models.Provider
+	for _, provider := range providers {
+		if provider.HasAnyTag(tags) {
+			filtered = append(filtered, provider)
+		}
+	}
+	return filtered
+}
+
+// rawBodyConflicts are the flags that build the request body, which a raw
+// body replaces
+var rawBodyConflicts = []string{
+	"message", "max-tokens", "streaming", "temperature", "top-p", "seed", "stop",
+	"frequency-penalty", "presence-penalty", "n", "logprobs", "top-logprobs", "response-format", "json-schema",
+	"messages-file", "conversation", "test-cases", "trace", "image", "randomize-prompt",
+}
+
+// checkRawBodyFlags rejects flags that a raw body would silently override
+func checkRawBodyFlags(cmd *cobra.Command) error {
+	for _, name := range rawBodyConflicts {
+		if cmd.Flags().Changed(name) {
+			return fmt.Errorf("--raw-body cannot be combined with --%s, as the body sets the whole request", name)
+		}
+	}
 	return nil
 }
 
-// BenchmarkResultsFile represents the structure of saved benchmark results
-type BenchmarkResultsFile struct {
-	Timestamp time.Time                                `yaml:"timestamp"`
-	Metadata  BenchmarkMetadata                        `yaml:"metadata"`
-	Summaries map[string]models.BenchmarkSummary       `yaml:"summaries"`
-	Results   map[string][]models.BenchmarkResult      `yaml:"results"`
+// loadRawBody reads a raw request body, which must be a JSON object and
+// can't ask for a stream, as raw requests are measured without streaming
+func loadRawBody(filename string) (json.RawMessage, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON object: %w", err)
+	}
+	if stream, ok := body["stream"]; ok && string(stream) == "true" {
+		return nil, fmt.Errorf("streaming raw bodies are not supported")
+	}
+
+	return json.RawMessage(data), nil
 }
 
-// BenchmarkMetadata contains information about the benchmark run
-type BenchmarkMetadata struct {
-	Message     string `yaml:"message"`
-	Requests    int    `yaml:"requests"`
-	Concurrency int    `yaml:"concurrency"`
-	MaxTokens   int    `yaml:"max_tokens"`
-	Streaming   bool   `yaml:"streaming"`
+// loadTrace reads a JSONL trace, one {offset_ms, prompt} request per line,
+// whose offsets must not decrease
+func loadTrace(filename string) ([]models.TraceEntry, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	var trace []models.TraceEntry
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		var entry models.TraceEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		if entry.Prompt == "" {
+			return nil, fmt.Errorf("line %d: prompt is required", i+1)
+		}
+		if entry.OffsetMs < 0 {
+			return nil, fmt.Errorf("line %d: offset_ms cannot be negative", i+1)
+		}
+		if len(trace) > 0 && entry.OffsetMs < trace[len(trace)-1].OffsetMs {
+			return nil, fmt.Errorf("line %d: offset_ms goes back in time, the trace must be sorted", i+1)
+		}
+		trace = append(trace, entry)
+	}
+
+	if len(trace) == 0 {
+		return nil, fmt.Errorf("no requests found")
+	}
+
+	return trace, nil
 }
 
-// saveBenchmarkResults saves benchmark results to a YAML file
-func saveBenchmarkResults(summaries map[string]models.BenchmarkSummary, results map[string][]models.BenchmarkResult, filename string) error {
-	// Create directory if it doesn't exist
-	dir := filepath.Dir(filename)
-	if dir != "." {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return fmt.Errorf("failed to create directory %s: %w", dir, err)
+// loadImage returns an image URL as is, or a local image file as a base64
+// data URL
+func loadImage(filename string) (string, error) {
+	if strings.HasPrefix(filename, "http://") || strings.HasPrefix(filename, "https://") {
+		return filename, nil
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+
+	mediaType := http.DetectContentType(data)
+	if !strings.HasPrefix(mediaType, "image/") {
+		return "", fmt.Errorf("not an image (detected %s)", mediaType)
+	}
+
+	return "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// loadMessagesFile reads prompts from a YAML list or a plain text file
+// with one prompt per line
+func loadMessagesFile(filename string) ([]string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	var prompts []string
+	ext := strings.ToLower(filepath.Ext(filename))
+	if ext == ".yaml" || ext == ".yml" {
+		if err := yaml.Unmarshal(data, &prompts); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+	} else {
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line != "" {
+				prompts = append(prompts, line)
+			}
 		}
 	}
 
-	// Create the results file structure
-	resultsFile := BenchmarkResultsFile{
-		Timestamp: time.Now(),
-		Metadata: BenchmarkMetadata{
-			Message:     message,
-			Requests:    configMgr.GetBenchmarkConfig().Requests,
-			Concurrency: configMgr.GetBenchmarkConfig().Concurrency,
-			MaxTokens:   maxTokens,
-			Streaming:   streaming,
-		},
-		Summaries: summaries,
-		Results:   results,
+	if len(prompts) == 0 {
+		return nil, fmt.Errorf("no prompts found")
+	}
+
+	return prompts, nil
+}
+
+// loadConversation reads a multi-turn conversation from a YAML file
+func loadConversation(filename string) (*models.Conversation, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	var conv models.Conversation
+	if err := yaml.Unmarshal(data, &conv); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+
+	if len(conv.Turns) == 0 {
+		return nil, fmt.Errorf("no turns found")
+	}
+
+	return &conv, nil
+}
+
+// loadTestCases reads prompts paired with expected answers from a YAML list
+func loadTestCases(filename string) ([]models.TestCase, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	var testCases []models.TestCase
+	if err := yaml.Unmarshal(data, &testCases); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+
+	if len(testCases) == 0 {
+		return nil, fmt.Errorf("no test cases found")
 	}
+	for i := range testCases {
+		if err := testCases[i].Compile(); err != nil {
+			return nil, fmt.Errorf("test case %d: %w", i+1, err)
+		}
+	}
+
+	return testCases, nil
+}
 
-	// Marshal to YAML
-	yamlData, err := yaml.Marshal(resultsFile)
+// loadJSONSchema reads a JSON schema document used for structured output
+func loadJSONSchema(filename string) (map[string]any, error) {
+	data, err := os.ReadFile(filename)
 	if err != nil {
-		return fmt.Errorf("failed to marshal results to YAML: %w", err)
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	var schema map[string]any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	return schema, nil
+}
+
+// printCompletionSummary prints one completion line per provider/model
+func printCompletionSummary(results map[string][]models.BenchmarkResult) {
+	out := statusOutput()
+	keys := make([]string, 0, len(results))
+	for key := range results {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		failed := 0
+		for _, result := range results[key] {
+			if !result.Success {
+				failed++
+			}
+		}
+		fmt.Fprintf(out, "%s: %d completed, %d failed\n", key, len(results[key]), failed)
+	}
+}
+
+// cliOutputFlags are the flags asking for output only the CLI mode produces
+var cliOutputFlags = []string{
+	"format", "json", "charts", "save", "output-dir", "quiet",
+	"summary-only", "max-error-rate", "max-p99", "baseline", "max-regression", "compare-models",
+	"matrix", "matrix-concurrency", "matrix-max-tokens", "runs", "print-request", "archive", "archive-charts",
+	"sort", "reverse", "chart-image", "influx", "influx-per-request", "influx-measurement", "influx-tag-keys",
+	"score", "score-weights",
+}
+
+// defaultsToTUI reports whether the TUI should be launched without being
+// asked for: on a terminal, unless a CLI output was requested, so scripts
+// and pipes keep getting text output
+func defaultsToTUI(cmd *cobra.Command) bool {
+	if !isTerminal(os.Stdout) || !isTerminal(os.Stdin) {
+		return false
 	}
+	return !cliOutputRequested(cmd)
+}
 
-	// Write to file
-	if err := os.WriteFile(filename, yamlData, 0644); err != nil {
-		return fmt.Errorf("failed to write results to file: %w", err)
+// cliOutputRequested reports whether any of the cliOutputFlags was given
+func cliOutputRequested(cmd *cobra.Command) bool {
+	for _, name := range cliOutputFlags {
+		if cmd.Flags().Changed(name) {
+			return true
+		}
 	}
+	return false
+}
 
+// isTerminal reports whether the given file is attached to a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// generatedSaveName is the value of a bare --save, which saves to a
+// timestamped file
+const generatedSaveName = "<generated>"
+
+// takeSaveFilename takes the filename of "--save results.yaml": as --save
+// can be given alone, its filename is left as a positional argument
+func takeSaveFilename(args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+	if saveResults != generatedSaveName || len(args) > 1 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(args, " "))
+	}
+	saveResults = args[0]
 	return nil
 }
+
+// resolveSavePath returns where results should be saved, combining --save
+// and --output-dir, or an empty string if saving was not requested. A bare
+// --save, like --output-dir alone, saves to a timestamped file in the
+// output directory or the current one
+func resolveSavePath(now time.Time) string {
+	filename := saveResults
+	if filename == "" && outputDir == "" {
+		return ""
+	}
+	if filename == "" || filename == generatedSaveName {
+		filename = fmt.Sprintf("llmbench-%s.yaml", now.Format("20060102-150405"))
+	}
+
+	if outputDir != "" && !filepath.IsAbs(filename) {
+		filename = filepath.Join(outputDir, filename)
+	}
+
+	return filename
+}
+
+// saveBenchmarkResults saves benchmark results to a YAML file
+func saveBenchmarkResults(config models.BenchmarkConfig, request models.BenchmarkRequest, summaries map[string]models.BenchmarkSummary, results map[string][]models.BenchmarkResult, filename string) error {
+	return storage.SaveResults(filename, runResultsFile(config, request, summaries, results))
+}
+
+// runResultsFile creates the results file of a run, leaving its per-request
+// results out with --summary-only
+func runResultsFile(config models.BenchmarkConfig, request models.BenchmarkRequest, summaries map[string]models.BenchmarkSummary, results map[string][]models.BenchmarkResult) *models.BenchmarkResultsFile {
+	resultsFile := newResultsFile(config, request)
+	resultsFile.Summaries = summaries
+	if !summaryOnly {
+		resultsFile.Results = results
+	}
+	return resultsFile
+}
+
+// newResultsFile creates a results file describing this run, to be filled
+// in with its results
+func newResultsFile(config models.BenchmarkConfig, request models.BenchmarkRequest) *models.BenchmarkResultsFile {
+	metadata := models.NewBenchmarkMetadata(request, config)
+	metadata.MessagesFile = messagesFile
+	metadata.ConversationFile = conversation
+	metadata.TestCasesFile = testCasesFile
+	metadata.Images = imageFiles
+	metadata.TraceFile = traceFile
+	metadata.RawBodyFile = rawBodyFile
+
+	return &models.BenchmarkResultsFile{
+		Timestamp: time.Now(),
+		Metadata:  metadata,
+	}
+}
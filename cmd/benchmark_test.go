@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func TestSaveFlag(t *testing.T) {
+	now := time.Date(2024, 1, 15, 15, 30, 0, 0, time.UTC)
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--save"}, "llmbench-20240115-153000.yaml"},
+		{[]string{"--save", "--output-dir", "runs"}, filepath.Join("runs", "llmbench-20240115-153000.yaml")},
+		{[]string{"--save", "results.yaml"}, "results.yaml"},
+		{[]string{"--save=results.yaml", "--output-dir", "runs"}, filepath.Join("runs", "results.yaml")},
+		{[]string{"--output-dir", "runs"}, filepath.Join("runs", "llmbench-20240115-153000.yaml")},
+		{nil, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprint(test.args), func(t *testing.T) {
+			flags := benchmarkCmd.Flags()
+			t.Cleanup(func() {
+				saveResults, outputDir = "", ""
+				flags.Lookup("save").Changed = false
+				flags.Lookup("output-dir").Changed = false
+			})
+
+			if err := flags.Parse(test.args); err != nil {
+				t.Fatalf("parse %v: %v", test.args, err)
+			}
+			if err := takeSaveFilename(flags.Args()); err != nil {
+				t.Fatalf("takeSaveFilename: %v", err)
+			}
+			if got := resolveSavePath(now); got != test.want {
+				t.Errorf("resolveSavePath() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestTakeSaveFilenameRejectsStrayArguments(t *testing.T) {
+	t.Cleanup(func() { saveResults = "" })
+
+	saveResults = "results.yaml"
+	if err := takeSaveFilename([]string{"extra"}); err == nil {
+		t.Error("takeSaveFilename with a --save filename and an argument succeeded, want an error")
+	}
+	saveResults = generatedSaveName
+	if err := takeSaveFilename([]string{"a.yaml", "b.yaml"}); err == nil {
+		t.Error("takeSaveFilename with two arguments succeeded, want an error")
+	}
+}
+
+func TestCLIOutputRequested(t *testing.T) {
+	tests := []struct {
+		flags []string
+		want  bool
+	}{
+		{nil, false},
+		{[]string{"requests", "streaming"}, false},
+		{[]string{"format"}, true},
+		{[]string{"save"}, true},
+		{[]string{"score"}, true},
+		{[]string{"score-weights"}, true},
+	}
+
+	for _, test := range tests {
+		// Stand-ins for the benchmark flags, so that setting them leaves
+		// the command's own alone
+		cmd := &cobra.Command{}
+		for _, name := range append([]string{"requests", "streaming"}, cliOutputFlags...) {
+			cmd.Flags().String(name, "", "")
+		}
+		for _, name := range test.flags {
+			if err := cmd.Flags().Set(name, "x"); err != nil {
+				t.Fatalf("set --%s: %v", name, err)
+			}
+		}
+
+		if got := cliOutputRequested(cmd); got != test.want {
+			t.Errorf("cliOutputRequested with %v = %v, want %v", test.flags, got, test.want)
+		}
+	}
+}
+
+func TestCLIOutputFlagsExist(t *testing.T) {
+	for _, name := range cliOutputFlags {
+		if benchmarkCmd.Flags().Lookup(name) == nil {
+			t.Errorf("cliOutputFlags lists --%s, which the benchmark command doesn't have", name)
+		}
+	}
+}
@@ -1,12 +1,20 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 
+	"llmbench/internal/config"
+	"llmbench/internal/models"
+
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
 func initConfiguration(cmd *cobra.Command, args []string) error {
@@ -71,6 +79,10 @@ If no path is provided, creates llmbench.yaml in the current directory.`,
 		Long:  `Validate the current configuration file for errors.`,
 		RunE:  validateConfig,
 	}
+
+	// Show flags
+	showJSON bool
+	showYAML bool
 )
 
 func init() {
@@ -78,6 +90,10 @@ func init() {
 	configCmd.AddCommand(initConfigCmd)
 	configCmd.AddCommand(showConfigCmd)
 	configCmd.AddCommand(validateConfigCmd)
+
+	showConfigCmd.Flags().BoolVar(&showJSON, "json", false, "Output the effective configuration as JSON, with API keys and header values masked")
+	showConfigCmd.Flags().BoolVar(&showYAML, "yaml", false, "Output the effective configuration as YAML, with API keys and header values masked")
+	showConfigCmd.MarkFlagsMutuallyExclusive("json", "yaml")
 }
 
 func showConfig(cmd *cobra.Command, args []string) error {
@@ -86,18 +102,57 @@ func showConfig(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("no configuration loaded")
 	}
 
+	if showJSON || showYAML {
+		return outputConfig(config)
+	}
+
 	fmt.Println("Current Configuration:")
 	fmt.Println("=====================")
 
+	fmt.Printf("Profile: %s\n", configMgr.Profile())
 	fmt.Printf("Requests: %d\n", config.Benchmark.Requests)
 	fmt.Printf("Concurrency: %d\n", config.Benchmark.Concurrency)
 	fmt.Printf("Timeout: %s\n", config.Benchmark.Timeout)
+	fmt.Printf("Connection Timeout: %s\n", config.Benchmark.ConnectionTimeout)
+	if config.Benchmark.StreamStallTimeout != "" {
+		fmt.Printf("Stream Stall Timeout: %s\n", config.Benchmark.StreamStallTimeout)
+	}
+	if config.Benchmark.UserAgent != "" {
+		fmt.Printf("User Agent: %s\n", config.Benchmark.UserAgent)
+	}
+	if config.Benchmark.RunID != "" {
+		fmt.Printf("Run ID: %s\n", config.Benchmark.RunID)
+	}
 	fmt.Printf("Providers: %d\n", len(config.Benchmark.Providers))
 
 	fmt.Println("\nProviders:")
 	for i, provider := range config.Benchmark.Providers {
 		fmt.Printf("  %d. %s\n", i+1, provider.Name)
 		fmt.Printf("     Base URL: %s\n", provider.BaseURL)
+		if len(provider.Tags) > 0 {
+			fmt.Printf("     Tags: %s\n", strings.Join(provider.Tags, ", "))
+		}
+		if provider.Timeout != "" {
+			fmt.Printf("     Timeout: %s\n", provider.Timeout)
+		}
+		if len(provider.ReasoningModels) > 0 {
+			fmt.Printf("     Reasoning Models: %s\n", strings.Join(provider.ReasoningModels, ", "))
+		}
+		if provider.Tokenizer != "" {
+			fmt.Printf("     Tokenizer: %s\n", provider.Tokenizer)
+		}
+		for _, model := range slices.Sorted(maps.Keys(provider.Tokenizers)) {
+			fmt.Printf("     Tokenizer (%s): %s\n", model, provider.Tokenizers[model])
+		}
+		if provider.Concurrency > 0 {
+			fmt.Printf("     Concurrency: %d\n", provider.Concurrency)
+		}
+		if provider.PromptTemplate != "" {
+			fmt.Printf("     Prompt Template: %q\n", provider.PromptTemplate)
+		}
+		if provider.IsAzure() {
+			fmt.Printf("     Type: azure (deployment: %s, api-version: %s)\n", provider.Deployment, provider.APIVersion)
+		}
 		if len(provider.Models) > 0 {
 			if len(provider.Models) == 1 {
 				fmt.Printf("     Model: %s\n", provider.Models[0])
@@ -107,19 +162,118 @@ func showConfig(cmd *cobra.Command, args []string) error {
 		} else {
 			fmt.Printf("     Models: none configured\n")
 		}
-		fmt.Printf("     API Key: %s\n", maskAPIKey(provider.APIKey))
+		switch keys := provider.Keys(); {
+		case provider.TokenAuth != nil && provider.TokenAuth.Command != "":
+			fmt.Printf("     Bearer Token: from command %q\n", provider.TokenAuth.Command)
+		case provider.TokenAuth != nil:
+			fmt.Printf("     Bearer Token: from %s (client %s)\n", provider.TokenAuth.URL, provider.TokenAuth.ClientID)
+		case len(keys) == 0:
+			fmt.Printf("     API Key: %s\n", maskAPIKey(""))
+		case len(keys) == 1:
+			fmt.Printf("     API Key: %s\n", maskAPIKey(keys[0]))
+		default:
+			fmt.Printf("     API Keys: %d, rotated round-robin\n", len(keys))
+		}
+		if len(provider.Headers) > 0 {
+			headerNames := make([]string, 0, len(provider.Headers))
+			for name := range provider.Headers {
+				headerNames = append(headerNames, name)
+			}
+			sort.Strings(headerNames)
+			fmt.Printf("     Headers: %s\n", strings.Join(headerNames, ", "))
+		}
+		if provider.ProxyURL != "" {
+			fmt.Printf("     Proxy: %s\n", provider.ProxyURL)
+		}
+		if provider.InsecureSkipVerify {
+			fmt.Printf("     TLS Verification: disabled ⚠️\n")
+		}
 	}
 
 	return nil
 }
 
+// shownConfig is the effective configuration as printed by config show
+type shownConfig struct {
+	Profile       string `yaml:"profile"`
+	config.Config `yaml:",inline"`
+}
+
+// outputConfig prints the effective configuration, defaults included, as
+// JSON or YAML with its secrets masked
+func outputConfig(cfg *config.Config) error {
+	shown := shownConfig{Profile: configMgr.Profile(), Config: maskConfig(*cfg)}
+
+	data, err := yaml.Marshal(shown)
+	if err != nil {
+		return fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	if showYAML {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+
+	// Going through YAML keeps the snake_case keys of the config file
+	var generic map[string]any
+	if err := yaml.Unmarshal(data, &generic); err != nil {
+		return fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(generic)
+}
+
+// maskConfig returns a copy of the configuration with API keys and header
+// values masked, as headers often carry credentials too
+func maskConfig(cfg config.Config) config.Config {
+	providers := make([]models.Provider, len(cfg.Benchmark.Providers))
+	for i, provider := range cfg.Benchmark.Providers {
+		provider.APIKey = maskAPIKey(provider.APIKey)
+		if len(provider.APIKeys) > 0 {
+			keys := make([]string, len(provider.APIKeys))
+			for j, key := range provider.APIKeys {
+				keys[j] = maskAPIKey(key)
+			}
+			provider.APIKeys = keys
+		}
+		if provider.TokenAuth != nil && provider.TokenAuth.ClientSecret != "" {
+			auth := *provider.TokenAuth
+			auth.ClientSecret = maskAPIKey(auth.ClientSecret)
+			provider.TokenAuth = &auth
+		}
+		provider.Headers = maskHeaders(provider.Headers)
+		providers[i] = provider
+	}
+	cfg.Benchmark.Providers = providers
+
+	if cfg.Benchmark.Telemetry != nil {
+		telemetry := *cfg.Benchmark.Telemetry
+		telemetry.Headers = maskHeaders(telemetry.Headers)
+		cfg.Benchmark.Telemetry = &telemetry
+	}
+
+	return cfg
+}
+
+// maskHeaders returns a copy of headers with every value masked
+func maskHeaders(headers map[string]string) map[string]string {
+	if len(headers) == 0 {
+		return headers
+	}
+	masked := make(map[string]string, len(headers))
+	for name, value := range headers {
+		masked[name] = maskAPIKey(value)
+	}
+	return masked
+}
+
 func validateConfig(cmd *cobra.Command, args []string) error {
 	config := configMgr.GetConfig()
 	if config == nil {
 		return fmt.Errorf("no configuration loaded")
 	}
 
-	fmt.Println("✅ Configuration is valid")
+	fmt.Printf("✅ Configuration is valid (profile: %s)\n", configMgr.Profile())
 	fmt.Printf("Found %d provider(s) configured\n", len(config.Benchmark.Providers))
 
 	return nil
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"llmbench/internal/dashboard"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	dashboardCmd = &cobra.Command{
+		Use:   "dashboard <results-dir>",
+		Short: "Browse saved results in a web dashboard",
+		Long: `Start a local web server with a dashboard of the results saved in a
+directory. The page lists every saved run and shows the summaries of the
+selected one as sortable tables and latency/throughput charts. It refreshes
+every few seconds, so runs saved while the dashboard is open show up.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runDashboard,
+	}
+
+	// Dashboard flags
+	dashboardAddr string
+)
+
+func init() {
+	rootCmd.AddCommand(dashboardCmd)
+
+	dashboardCmd.Flags().StringVar(&dashboardAddr, "addr", "localhost:8090", "Address to listen on")
+}
+
+func runDashboard(cmd *cobra.Command, args []string) error {
+	dir := args[0]
+	if info, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("failed to open results directory: %w", err)
+	} else if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+
+	httpServer := &http.Server{
+		Addr:              dashboardAddr,
+		Handler:           dashboard.New(dir).Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	fmt.Printf("📊 Dashboard of %s at http://%s\n", dir, dashboardAddr)
+	if err := httpServer.ListenAndServe(); err != nil {
+		return fmt.Errorf("server failed: %w", err)
+	}
+
+	return nil
+}
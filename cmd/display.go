@@ -2,14 +2,13 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 	"strings"
 
-	"llmbench/internal/charts"
 	"llmbench/internal/models"
+	"llmbench/internal/service"
+	"llmbench/internal/storage"
 
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3"
 )
 
 var (
@@ -26,6 +25,9 @@ re-running the benchmark. You can display either text summary or charts.`,
 	// Display flags
 	displayCharts bool
 	displayJSON   bool
+	displayFormat string
+	displayFilter []string
+	showOutliers  bool
 )
 
 func init() {
@@ -33,102 +35,152 @@ func init() {
 
 	displayCmd.Flags().BoolVar(&displayCharts, "charts", false, "Display bar charts for TTFT and throughput metrics")
 	displayCmd.Flags().BoolVar(&displayJSON, "json", false, "Output results in JSON format")
+	displayCmd.Flags().StringSliceVar(&displayFilter, "provider", nil, "Only show provider/models matching this glob or substring; repeatable")
+	displayCmd.Flags().BoolVar(&showOutliers, "outliers", false, "List requests whose response time is far from their provider/model median (over 3 scaled median absolute deviations)")
+	displayCmd.Flags().BoolVar(&summaryOnly, "summary-only", false, "Leave per-request results out of JSON output, keeping only the summaries")
+	displayCmd.Flags().StringVar(&sortBy, "sort", sortName, "Order of the summaries: name, latency, throughput, error-rate or tokens (best first)")
+	displayCmd.Flags().BoolVar(&reverseSort, "reverse", false, "Reverse the --sort order")
+	displayCmd.Flags().StringVar(&displayFormat, "format", "", "Output format: text, json, csv, markdown, html or charts (default text)")
+
+	displayCmd.Flags().MarkDeprecated("json", "use --format json instead")
+	displayCmd.Flags().MarkDeprecated("charts", "use --format charts instead")
 }
 
 func runDisplay(cmd *cobra.Command, args []string) error {
 	filename := args[0]
 
+	format, err := resolveOutputFormat(displayFormat, displayJSON, displayCharts)
+	if err != nil {
+		return err
+	}
+
+	if err := validateSort(); err != nil {
+		return err
+	}
+	if summaryOnly && format == formatCSV {
+		return fmt.Errorf("--summary-only cannot be combined with the csv format, which lists requests")
+	}
+	if showOutliers && format != formatText {
+		return fmt.Errorf("--outliers only applies to the text format")
+	}
+
 	// Load benchmark results from YAML file
-	resultsFile, err := loadBenchmarkResults(filename)
+	resultsFile, err := storage.LoadResults(filename)
 	if err != nil {
 		return fmt.Errorf("failed to load results from %s: %w", filename, err)
 	}
 
-	// Display file metadata
-	fmt.Printf("📁 Loaded results from: %s\n", filename)
-	fmt.Printf("🕒 Benchmark run time: %s\n", resultsFile.Timestamp.Format("2006-01-02 15:04:05"))
-	fmt.Printf("💬 Message: %s\n", resultsFile.Metadata.Message)
-	fmt.Printf("📊 Requests: %d, Concurrency: %d, Max Tokens: %d\n", 
-		resultsFile.Metadata.Requests, resultsFile.Metadata.Concurrency, resultsFile.Metadata.MaxTokens)
-	if resultsFile.Metadata.Streaming {
-		fmt.Printf("🚀 Streaming: enabled\n")
+	if resultsFile.Matrix != nil {
+		return displayMatrix(filename, format, resultsFile)
 	}
-	fmt.Println()
 
-	if displayJSON {
-		return outputJSONResults(resultsFile.Summaries, resultsFile.Results)
+	summaries, results := filterByProvider(resultsFile.Summaries, resultsFile.Results, displayFilter)
+	if len(summaries) == 0 && len(results) == 0 {
+		return fmt.Errorf("no results in %s match --provider %s", filename, strings.Join(displayFilter, ", "))
 	}
 
-	return displayTextResults(resultsFile.Summaries)
-}
+	// Machine-readable formats get the results alone so they can be piped
+	if format == formatText || format == formatCharts {
+		printMetadata(filename, resultsFile)
+	}
 
-func displayTextResults(summaries map[string]models.BenchmarkSummary) error {
-	// If charts are requested, show only charts
-	if displayCharts {
-		fmt.Println(strings.Repeat("=", 80))
-		fmt.Println("BENCHMARK CHARTS")
-		fmt.Println(strings.Repeat("=", 80))
-		
-		// Create chart generator with appropriate dimensions
-		chartGen := charts.NewChartGenerator(60, 15)
-		chartsOutput := chartGen.GenerateAllCharts(summaries)
-		fmt.Print(chartsOutput)
-		fmt.Println(strings.Repeat("=", 80))
-		return nil
-	}
-
-	// Otherwise, show text summary
-	fmt.Println(strings.Repeat("=", 80))
-	fmt.Println("BENCHMARK RESULTS")
-	fmt.Println(strings.Repeat("=", 80))
-
-	for _, summary := range summaries {
-		// Display provider and model name clearly
-		if summary.ModelName != "" {
-			fmt.Printf("\n📊 %s - %s\n", strings.ToUpper(summary.Provider), summary.ModelName)
-		} else {
-			fmt.Printf("\n📊 %s\n", strings.ToUpper(summary.Provider))
-		}
-		fmt.Println(strings.Repeat("-", 50))
-		fmt.Printf("Total Requests:     %d\n", summary.TotalRequests)
-		fmt.Printf("Successful:         %d\n", summary.SuccessfulReqs)
-		fmt.Printf("Failed:             %d\n", summary.FailedRequests)
-		fmt.Printf("Error Rate:         %.2f%%\n", summary.ErrorRate)
-		fmt.Printf("Avg Response Time:  %v\n", summary.AvgResponseTime)
-		fmt.Printf("Min Response Time:  %v\n", summary.MinResponseTime)
-		fmt.Printf("Max Response Time:  %v\n", summary.MaxResponseTime)
-		fmt.Printf("Total Tokens:       %d\n", summary.TotalTokens)
-		
-		// Display streaming metrics if available
-		if summary.IsStreaming {
-			fmt.Println("\n🚀 STREAMING METRICS")
-			fmt.Println(strings.Repeat("-", 20))
-			fmt.Printf("Avg Time to First Token: %v\n", summary.AvgTimeToFirstToken)
-			fmt.Printf("Min Time to First Token: %v\n", summary.MinTimeToFirstToken)
-			fmt.Printf("Max Time to First Token: %v\n", summary.MaxTimeToFirstToken)
-			fmt.Printf("Avg Token Throughput:    %.2f tokens/sec\n", summary.AvgTokenThroughput)
-			fmt.Printf("Min Token Throughput:    %.2f tokens/sec\n", summary.MinTokenThroughput)
-			fmt.Printf("Max Token Throughput:    %.2f tokens/sec\n", summary.MaxTokenThroughput)
-		}
+	if err := writeResults(format, summaries, results); err != nil {
+		return err
 	}
 
-	fmt.Println("\n" + strings.Repeat("=", 80))
+	// Files saved before outliers were flagged get them flagged here
+	if showOutliers {
+		service.MarkOutliers(results)
+		printOutliers(results)
+	}
 	return nil
 }
 
-// loadBenchmarkResults loads benchmark results from a YAML file
-func loadBenchmarkResults(filename string) (*BenchmarkResultsFile, error) {
-	// Read the file
-	data, err := os.ReadFile(filename)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read file: %w", err)
+// displayMatrix shows the saved results of a matrix run
+func displayMatrix(filename, format string, resultsFile *models.BenchmarkResultsFile) error {
+	if format != formatText && format != formatJSON {
+		return fmt.Errorf("matrix results only support the text and json formats")
+	}
+
+	matrix := filterMatrixByProvider(resultsFile.Matrix, displayFilter)
+	if len(matrix.Keys()) == 0 {
+		return fmt.Errorf("no results in %s match --provider %s", filename, strings.Join(displayFilter, ", "))
 	}
 
-	// Unmarshal YAML
-	var resultsFile BenchmarkResultsFile
-	if err := yaml.Unmarshal(data, &resultsFile); err != nil {
-		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	if format == formatText {
+		printMetadata(filename, resultsFile)
 	}
+	return writeMatrixResults(format, matrix)
+}
+
+// printMetadata prints where and how the saved benchmark was run
+func printMetadata(filename string, resultsFile *models.BenchmarkResultsFile) {
+	metadata := resultsFile.Metadata
 
-	return &resultsFile, nil
+	fmt.Printf("📁 Loaded results from: %s\n", filename)
+	fmt.Printf("🕒 Benchmark run time: %s\n", resultsFile.Timestamp.Format("2006-01-02 15:04:05"))
+	if metadata.KeepAlives != nil {
+		if *metadata.KeepAlives {
+			fmt.Printf("🔌 Keep-alives: on, connections reused\n")
+		} else {
+			fmt.Printf("🔌 Keep-alives: off, a new connection per request\n")
+		}
+	}
+	if metadata.RunID != "" {
+		fmt.Printf("🏷️  Run ID: %s\n", metadata.RunID)
+	}
+	fmt.Printf("💬 Message: %s\n", metadata.Message)
+	fmt.Printf("📊 Requests: %d, Concurrency: %d, Max Tokens: %d\n", 
+		metadata.Requests, metadata.Concurrency, metadata.MaxTokens)
+	if resultsFile.Matrix != nil {
+		fmt.Printf("🧮 Matrix: concurrency %v × max tokens %v\n", resultsFile.Matrix.Concurrency, resultsFile.Matrix.MaxTokens)
+	}
+	if metadata.Streaming {
+		fmt.Printf("🚀 Streaming: enabled\n")
+	}
+	if metadata.Temperature != nil {
+		fmt.Printf("🌡️  Temperature: %g\n", *metadata.Temperature)
+	}
+	if metadata.TopP != nil {
+		fmt.Printf("🎯 Top P: %g\n", *metadata.TopP)
+	}
+	if metadata.Seed != nil {
+		fmt.Printf("🎲 Seed: %d\n", *metadata.Seed)
+	}
+	if len(metadata.Stop) > 0 {
+		fmt.Printf("✋ Stop: %q\n", metadata.Stop)
+	}
+	if metadata.FrequencyPenalty != nil {
+		fmt.Printf("🔁 Frequency Penalty: %g\n", *metadata.FrequencyPenalty)
+	}
+	if metadata.PresencePenalty != nil {
+		fmt.Printf("🔁 Presence Penalty: %g\n", *metadata.PresencePenalty)
+	}
+	if metadata.N > 1 {
+		fmt.Printf("🔢 Choices per Request: %d\n", metadata.N)
+	}
+	if metadata.TopLogprobs != nil {
+		fmt.Printf("📉 Logprobs: requested, top %d\n", *metadata.TopLogprobs)
+	} else if metadata.Logprobs {
+		fmt.Printf("📉 Logprobs: requested\n")
+	}
+	if metadata.RandomizedPrompt {
+		fmt.Printf("🎰 Randomized Prompts: a nonce was appended to every prompt\n")
+	}
+	if metadata.RawBodyFile != "" {
+		fmt.Printf("📦 Raw Body: %s\n", metadata.RawBodyFile)
+	}
+	if len(metadata.Images) > 0 {
+		fmt.Printf("🖼️  Images: %s\n", strings.Join(metadata.Images, ", "))
+	}
+	if metadata.TraceEntries > 0 {
+		fmt.Printf("📼 Trace: %d requests replayed from %s\n", metadata.TraceEntries, metadata.TraceFile)
+	}
+	if metadata.ConversationTurns > 0 {
+		fmt.Printf("🗨️  Conversation: %d turns\n", metadata.ConversationTurns)
+	}
+	if metadata.ResponseFormat != "" {
+		fmt.Printf("🧾 Response format: %s\n", metadata.ResponseFormat)
+	}
+	fmt.Println()
 }
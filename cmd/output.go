@@ -0,0 +1,1072 @@
+package cmd
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"llmbench/internal/charts"
+	"llmbench/internal/models"
+	"llmbench/internal/service"
+	"llmbench/internal/storage"
+	"llmbench/internal/units"
+)
+
+// Output formats accepted by --format
+const (
+	formatText     = "text"
+	formatJSON     = "json"
+	formatCSV      = "csv"
+	formatMarkdown = "markdown"
+	formatHTML     = "html"
+	formatCharts   = "charts"
+)
+
+// outputFormats lists the valid --format values
+var outputFormats = []string{formatText, formatJSON, formatCSV, formatMarkdown, formatHTML, formatCharts}
+
+// resolveOutputFormat validates --format, falling back to the deprecated
+// --json and --charts flags when it is not set
+func resolveOutputFormat(format string, jsonFlag, chartsFlag bool) (string, error) {
+	if format == "" {
+		switch {
+		case jsonFlag && chartsFlag:
+			return "", fmt.Errorf("--json and --charts cannot be combined, use --format")
+		case jsonFlag:
+			return formatJSON, nil
+		case chartsFlag:
+			return formatCharts, nil
+		default:
+			return formatText, nil
+		}
+	}
+
+	format = strings.ToLower(format)
+	valid := false
+	for _, f := range outputFormats {
+		if f == format {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return "", fmt.Errorf("invalid format %q: must be one of %s", format, strings.Join(outputFormats, ", "))
+	}
+
+	if (jsonFlag && format != formatJSON) || (chartsFlag && format != formatCharts) {
+		return "", fmt.Errorf("--format %s conflicts with --json/--charts", format)
+	}
+
+	return format, nil
+}
+
+// writeResults prints summaries and results to stdout in the given format
+func writeResults(format string, summaries map[string]models.BenchmarkSummary, results map[string][]models.BenchmarkResult) error {
+	switch format {
+	case formatJSON:
+		return outputJSONResults(summaries, results)
+	case formatCSV:
+		return writeCSVResults(os.Stdout, results)
+	case formatMarkdown:
+		return writeMarkdownResults(os.Stdout, summaries)
+	case formatHTML:
+		return writeHTMLResults(os.Stdout, summaries)
+	case formatCharts:
+		return outputChartResults(summaries, results)
+	default:
+		return outputTextResults(summaries, results)
+	}
+}
+
+// Orders accepted by --sort
+const (
+	sortName       = "name"
+	sortLatency    = "latency"
+	sortThroughput = "throughput"
+	sortErrorRate  = "error-rate"
+	sortTokens     = "tokens"
+)
+
+// sortOrders lists the valid --sort values
+var sortOrders = []string{sortName, sortLatency, sortThroughput, sortErrorRate, sortTokens}
+
+// Sort flags, shared by the benchmark and display commands
+var (
+	sortBy      string
+	reverseSort bool
+)
+
+// validateSort checks the --sort value
+func validateSort() error {
+	if !slices.Contains(sortOrders, sortBy) {
+		return fmt.Errorf("invalid --sort %q: must be one of %s", sortBy, strings.Join(sortOrders, ", "))
+	}
+	return nil
+}
+
+// summaryLess orders two summaries by --sort, best first: lowest latency
+// of successful requests or error rate, highest throughput, most tokens. It
+// reports whether the order was decided, so that ties fall back to the name
+func summaryLess(a, b models.BenchmarkSummary) (less, decided bool) {
+	switch sortBy {
+	case sortLatency:
+		latencyA, latencyB := successLatency(a), successLatency(b)
+		return latencyA < latencyB, latencyA != latencyB
+	case sortThroughput:
+		return a.AvgTokenThroughput > b.AvgTokenThroughput, a.AvgTokenThroughput != b.AvgTokenThroughput
+	case sortErrorRate:
+		return a.ErrorRate < b.ErrorRate, a.ErrorRate != b.ErrorRate
+	case sortTokens:
+		return a.TotalTokens > b.TotalTokens, a.TotalTokens != b.TotalTokens
+	}
+	return false, false
+}
+
+// successLatency returns the average response time of a summary's
+// successful requests; files saved without it fall back to the average over
+// all requests
+func successLatency(summary models.BenchmarkSummary) time.Duration {
+	if summary.AvgSuccessResponseTime > 0 {
+		return summary.AvgSuccessResponseTime
+	}
+	return summary.AvgResponseTime
+}
+
+// hasSortData reports whether a summary has a value to sort on: metrics
+// mean nothing without successful requests, and runs that didn't stream
+// have no throughput
+func hasSortData(summary models.BenchmarkSummary) bool {
+	switch sortBy {
+	case sortName:
+		return true
+	case sortThroughput:
+		return summary.SuccessfulReqs > 0 && summary.AvgTokenThroughput > 0
+	}
+	return summary.SuccessfulReqs > 0
+}
+
+// sortedSummaryKeys returns the summary keys in a stable order, by --sort
+// and then name, reversed with --reverse. Summaries without data to sort
+// on come last whatever the order, by name
+func sortedSummaryKeys(summaries map[string]models.BenchmarkSummary) []string {
+	var keys, missing []string
+	for key, summary := range summaries {
+		if hasSortData(summary) {
+			keys = append(keys, key)
+		} else {
+			missing = append(missing, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if less, decided := summaryLess(summaries[keys[i]], summaries[keys[j]]); decided {
+			return less
+		}
+		return keys[i] < keys[j]
+	})
+	if reverseSort {
+		slices.Reverse(keys)
+	}
+	sort.Strings(missing)
+	return append(keys, missing...)
+}
+
+// printUnsorted notes the summaries listed last for lack of data to sort
+// on, so that they aren't mistaken for the worst performers
+func printUnsorted(summaries map[string]models.BenchmarkSummary) {
+	var missing []string
+	for _, key := range slices.Sorted(maps.Keys(summaries)) {
+		if !hasSortData(summaries[key]) {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		fmt.Printf("ℹ️  No %s to sort on, listed last: %s\n", sortBy, strings.Join(missing, ", "))
+	}
+}
+
+// matchesProvider reports whether a provider/model key matches any of the
+// given patterns, either as a glob on the key or its provider name, or as
+// a substring of the key
+func matchesProvider(key string, patterns []string) bool {
+	provider, _, _ := strings.Cut(key, "/")
+	for _, pattern := range patterns {
+		if strings.Contains(key, pattern) {
+			return true
+		}
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, provider); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// filterByProvider keeps the summaries and results whose provider/model
+// key matches one of the patterns; no patterns keeps everything
+func filterByProvider(summaries map[string]models.BenchmarkSummary, results map[string][]models.BenchmarkResult, patterns []string) (map[string]models.BenchmarkSummary, map[string][]models.BenchmarkResult) {
+	if len(patterns) == 0 {
+		return summaries, results
+	}
+
+	filteredSummaries := make(map[string]models.BenchmarkSummary)
+	for key, summary := range summaries {
+		if matchesProvider(key, patterns) {
+			filteredSummaries[key] = summary
+		}
+	}
+	filteredResults := make(map[string][]models.BenchmarkResult)
+	for key, providerResults := range results {
+		if matchesProvider(key, patterns) {
+			filteredResults[key] = providerResults
+		}
+	}
+	return filteredSummaries, filteredResults
+}
+
+// outputJSONResults prints summaries and raw results as indented JSON,
+// leaving the results out with --summary-only
+func outputJSONResults(summaries map[string]models.BenchmarkSummary, results map[string][]models.BenchmarkResult) error {
+	if summaryOnly {
+		results = nil
+	}
+	output := struct {
+		Summaries map[string]models.BenchmarkSummary  `json:"summaries"`
+		Results   map[string][]models.BenchmarkResult `json:"results,omitempty"`
+	}{
+		Summaries: summaries,
+		Results:   results,
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(output)
+}
+
+// sparklineWidth caps the latency trend, one bar per request or bucket of requests
+const sparklineWidth = 60
+
+// outputTextResults prints a human-readable summary for every provider/model
+func outputTextResults(summaries map[string]models.BenchmarkSummary, results map[string][]models.BenchmarkResult) error {
+	fmt.Println("\n" + strings.Repeat("=", 80))
+	fmt.Println("BENCHMARK RESULTS")
+	fmt.Println(strings.Repeat("=", 80))
+	printSharedModels(summaries)
+	printUnsorted(summaries)
+
+	for _, key := range sortedSummaryKeys(summaries) {
+		summary := summaries[key]
+
+		// Display provider and model name clearly
+		if summary.ModelName != "" {
+			fmt.Printf("\n📊 %s - %s\n", strings.ToUpper(summary.Provider), summary.ModelName)
+		} else {
+			fmt.Printf("\n📊 %s\n", strings.ToUpper(summary.Provider))
+		}
+		fmt.Println(strings.Repeat("-", 50))
+		if summary.SkippedRequests > 0 {
+			fmt.Printf("Total Requests:     %d of %d planned (%d skipped)\n", summary.TotalRequests, summary.PlannedRequests, summary.SkippedRequests)
+		} else {
+			fmt.Printf("Total Requests:     %d\n", summary.TotalRequests)
+		}
+		if summary.NotStarted > 0 {
+			fmt.Printf("⏱️  Cut short:       %d requests not started (max duration reached)\n", summary.NotStarted)
+		}
+		if summary.FailFastSkipped > 0 {
+			fmt.Printf("🛑 Skipped:         %d requests not started (fail-fast after consecutive failures)\n", summary.FailFastSkipped)
+		}
+		fmt.Printf("Successful:         %d\n", summary.SuccessfulReqs)
+		fmt.Printf("Failed:             %d\n", summary.FailedRequests)
+		if summary.SkippedRequests > 0 {
+			fmt.Printf("Error Rate:         %.2f%% (of the requests that ran)\n", summary.ErrorRate)
+		} else {
+			fmt.Printf("Error Rate:         %.2f%%\n", summary.ErrorRate)
+		}
+		fmt.Printf("Avg Response Time:  %s\n", units.Duration(summary.AvgResponseTime))
+		fmt.Printf("Min Response Time:  %s\n", units.Duration(summary.MinResponseTime))
+		fmt.Printf("Max Response Time:  %s\n", units.Duration(summary.MaxResponseTime))
+		fmt.Printf("P50 Response Time:  %s\n", units.Duration(summary.P50ResponseTime))
+		fmt.Printf("P95 Response Time:  %s\n", units.Duration(summary.P95ResponseTime))
+		fmt.Printf("P99 Response Time:  %s\n", units.Duration(summary.P99ResponseTime))
+		if summary.ColdStartLatency > 0 {
+			fmt.Printf("Cold Start:         %s (first request to complete)\n", units.Duration(summary.ColdStartLatency))
+		}
+		if summary.Outliers > 0 {
+			fmt.Printf("Outliers:           %d (over 3 std devs from the mean)\n", summary.Outliers)
+		}
+		if sparkline := charts.LatencySparkline(results[key], sparklineWidth); len([]rune(sparkline)) > 1 {
+			fmt.Printf("Latency Trend:      %s (completion order)\n", sparkline)
+		}
+		fmt.Printf("Total Tokens:       %s\n", units.Int(summary.TotalTokens))
+		if summary.MaxOutputTokens > 0 {
+			fmt.Printf("Output Tokens:      avg %s, min %s, max %s\n", units.Float(summary.AvgOutputTokens, 1), units.Int(summary.MinOutputTokens), units.Int(summary.MaxOutputTokens))
+		}
+		if summary.TruncatedResponses > 0 {
+			fmt.Printf("Truncated:          %d responses (%.2f%%) hit max_tokens, capping their throughput\n", summary.TruncatedResponses, summary.TruncatedRate)
+		}
+		if summary.LogprobsResponses > 0 {
+			fmt.Printf("Logprobs:           %d of %d responses carried logprobs\n", summary.LogprobsResponses, summary.SuccessfulReqs)
+		}
+		if summary.EstimatedTokenCounts > 0 {
+			fmt.Printf("Estimated Tokens:   %d of %d responses counted without a known tokenizer (set one in config)\n", summary.EstimatedTokenCounts, summary.SuccessfulReqs)
+		}
+		if summary.CachedInputTokens > 0 {
+			fmt.Printf("Cached Input:       %s tokens, %.2f%% cache hit rate\n", units.Int(summary.CachedInputTokens), summary.CacheHitRate)
+		}
+		if summary.ReasoningTokens > 0 {
+			fmt.Printf("Reasoning Tokens:   %s, avg %s per request\n", units.Int(summary.ReasoningTokens), units.Float(summary.AvgReasoningTokens, 1))
+		}
+		if summary.AvgResponseBytes > 0 {
+			fmt.Printf("Response Size:      avg %.0f bytes, %.0f bytes/sec\n", summary.AvgResponseBytes, summary.ByteThroughput)
+		}
+		if summary.AvgTTFB > 0 {
+			fmt.Printf("Network (avg):      DNS %s, connect %s, TLS %s, TTFB %s\n", units.Duration(summary.AvgDNSTime),
+				units.Duration(summary.AvgConnectTime), units.Duration(summary.AvgTLSTime), units.Duration(summary.AvgTTFB))
+		}
+		if summary.GradedRequests > 0 {
+			fmt.Printf("Accuracy:           %.2f%% (%d graded responses)\n", summary.Accuracy, summary.GradedRequests)
+		}
+		
+		// Display streaming metrics if available
+		if summary.IsStreaming {
+			fmt.Println("\n🚀 STREAMING METRICS")
+			fmt.Println(strings.Repeat("-", 20))
+			fmt.Printf("Avg Time to First Token: %s\n", units.Duration(summary.AvgTimeToFirstToken))
+			fmt.Printf("Min Time to First Token: %s\n", units.Duration(summary.MinTimeToFirstToken))
+			fmt.Printf("Max Time to First Token: %s\n", units.Duration(summary.MaxTimeToFirstToken))
+			if summary.P50TimeToFirstToken > 0 {
+				fmt.Printf("TTFT Percentiles:        p50 %s, p90 %s, p99 %s\n", units.Duration(summary.P50TimeToFirstToken),
+					units.Duration(summary.P90TimeToFirstToken), units.Duration(summary.P99TimeToFirstToken))
+			}
+			fmt.Printf("Avg Token Throughput:    %.2f tokens/sec\n", summary.AvgTokenThroughput)
+			fmt.Printf("Min Token Throughput:    %.2f tokens/sec\n", summary.MinTokenThroughput)
+			fmt.Printf("Max Token Throughput:    %.2f tokens/sec\n", summary.MaxTokenThroughput)
+			fmt.Printf("Median Token Throughput: %.2f tokens/sec (per request)\n", summary.MedianTokenThroughput)
+			fmt.Printf("P90 Token Throughput:    %.2f tokens/sec (per request)\n", summary.P90TokenThroughput)
+			fmt.Printf("Overall Throughput:      %.2f tokens/sec (total tokens / total streaming time)\n", summary.OverallTokenThroughput)
+			if summary.EmptyResponses > 0 {
+				fmt.Printf("Empty Responses:         %d (excluded from streaming metrics)\n", summary.EmptyResponses)
+			}
+		}
+
+		printInterArrival(summary.InterArrival)
+		printTraceStats(summary.Trace)
+		printTurnStats(summary.TurnStats)
+		printKeyStats(summary.KeyStats)
+		printErrorKinds(summary.ErrorKinds)
+	}
+
+	printScores(summaries)
+	printRunTotals(service.Totals(results))
+
+	fmt.Println("\n" + strings.Repeat("=", 80))
+	return nil
+}
+
+// printRunTotals prints the aggregate of every provider/model, including
+// the run's goodput; nothing is printed without timed results
+func printRunTotals(totals service.RunTotals) {
+	if totals.WallClock <= 0 {
+		return
+	}
+
+	fmt.Println("\n🌐 AGGREGATE")
+	fmt.Println(strings.Repeat("-", 20))
+	fmt.Printf("Requests:           %d (%d successful)\n", totals.Requests, totals.Successful)
+	fmt.Printf("Output Tokens:      %s\n", units.Int(totals.OutputTokens))
+	fmt.Printf("Wall-Clock Time:    %s\n", units.Duration(totals.WallClock))
+	fmt.Printf("Goodput:            %.2f tokens/sec (successful output tokens / wall-clock time)\n", totals.Goodput)
+}
+
+// printSLAResults prints whether each provider/model met its SLA
+func printSLAResults(results []service.SLAResult) {
+	if len(results) == 0 {
+		return
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Key < results[j].Key })
+
+	fmt.Println("\n📋 SLA")
+	fmt.Println(strings.Repeat("-", 20))
+	for _, result := range results {
+		if result.Met() {
+			fmt.Printf("✅ %s: met\n", result.Key)
+			continue
+		}
+		fmt.Printf("❌ %s: %s\n", result.Key, strings.Join(result.Violations, "; "))
+	}
+}
+
+// printBaselineResults prints every metric's change against the baseline,
+// flagging regressions
+func printBaselineResults(results []service.BaselineResult) {
+	if len(results) == 0 {
+		return
+	}
+
+	fmt.Printf("\n📐 BASELINE (%s, max regression %g)\n", baselineFile, maxRegression)
+	fmt.Println(strings.Repeat("-", 20))
+	for _, result := range results {
+		if result.Missing {
+			fmt.Printf("➖ %s: not in baseline\n", result.Key)
+			continue
+		}
+
+		status := "✅"
+		if len(result.Regressions()) > 0 {
+			status = "❌"
+		}
+		fmt.Printf("%s %s\n", status, result.Key)
+		for _, change := range result.Changes {
+			marker := " "
+			if change.Regression {
+				marker = "!"
+			}
+			fmt.Printf("  %s %-12s %14s -> %-14s %+.1f%s\n", marker, change.Metric, change.Baseline, change.Current, change.Change, change.Unit)
+		}
+	}
+}
+
+// printErrorKinds prints a histogram of failed requests per error kind
+func printErrorKinds(kinds map[string]int) {
+	if len(kinds) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(kinds))
+	maxCount := 0
+	for name, count := range kinds {
+		names = append(names, name)
+		maxCount = max(maxCount, count)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if kinds[names[i]] != kinds[names[j]] {
+			return kinds[names[i]] > kinds[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	fmt.Println("\n⚠️  ERRORS BY KIND")
+	fmt.Println(strings.Repeat("-", 20))
+	for _, name := range names {
+		bar := strings.Repeat("█", max(1, kinds[name]*30/maxCount))
+		fmt.Printf("%-12s %-30s %d\n", name, bar, kinds[name])
+	}
+}
+
+// printScores ranks providers by score, highlighting the winner; nothing is
+// printed when the run was not scored
+func printScores(summaries map[string]models.BenchmarkSummary) {
+	keys := sortedSummaryKeys(summaries)
+	scored := false
+	for _, key := range keys {
+		if summaries[key].Score > 0 {
+			scored = true
+			break
+		}
+	}
+	if !scored {
+		return
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		return summaries[keys[i]].Score > summaries[keys[j]].Score
+	})
+
+	fmt.Println("\n🏆 SCORES")
+	fmt.Println(strings.Repeat("-", 20))
+	for i, key := range keys {
+		marker := "  "
+		if i == 0 {
+			marker = "🏆"
+		}
+		fmt.Printf("%s %-40s %6.1f\n", marker, key, summaries[key].Score)
+	}
+}
+
+// outputModelComparison prints the models of a single provider side by side,
+// fastest first
+func outputModelComparison(provider string, summaries map[string]models.BenchmarkSummary) error {
+	keys := sortedSummaryKeys(summaries)
+	sort.SliceStable(keys, func(i, j int) bool {
+		return summaries[keys[i]].AvgResponseTime < summaries[keys[j]].AvgResponseTime
+	})
+
+	fmt.Println("\n" + strings.Repeat("=", 80))
+	fmt.Printf("MODEL COMPARISON - %s\n", strings.ToUpper(provider))
+	fmt.Println(strings.Repeat("=", 80))
+
+	fmt.Printf("\n%-30s %10s %10s %10s %10s %8s %10s %12s\n", "Model", "Avg", "P50", "P95", "P99", "Errors", "Avg TTFT", "Throughput")
+	fmt.Println(strings.Repeat("-", 110))
+	for _, key := range keys {
+		summary := summaries[key]
+		ttft, throughput := streamingColumns(summary)
+		fmt.Printf("%-30s %10s %10s %10s %10s %7.2f%% %10s %12s\n",
+			summary.ModelName,
+			units.Duration(summary.AvgResponseTime),
+			units.Duration(summary.P50ResponseTime),
+			units.Duration(summary.P95ResponseTime),
+			units.Duration(summary.P99ResponseTime),
+			summary.ErrorRate, ttft, throughput)
+	}
+
+	printScores(summaries)
+
+	fmt.Println("\n" + strings.Repeat("=", 80))
+	return nil
+}
+
+// outputChartResults prints bar charts for every summary, plus the
+// throughput-over-time charts for streaming runs
+func outputChartResults(summaries map[string]models.BenchmarkSummary, results map[string][]models.BenchmarkResult) error {
+	fmt.Println("\n" + strings.Repeat("=", 80))
+	fmt.Println("BENCHMARK CHARTS")
+	fmt.Println(strings.Repeat("=", 80))
+	printSharedModels(summaries)
+	fmt.Print(renderCharts(summaries, results))
+	fmt.Println(strings.Repeat("=", 80))
+	return nil
+}
+
+// printSharedModels warns about the model names served by several
+// providers, whose results are only told apart by their provider prefix
+func printSharedModels(summaries map[string]models.BenchmarkSummary) {
+	providers := make(map[string][]string)
+	for _, key := range slices.Sorted(maps.Keys(summaries)) {
+		summary := summaries[key]
+		if summary.ModelName != "" && !slices.Contains(providers[summary.ModelName], summary.Provider) {
+			providers[summary.ModelName] = append(providers[summary.ModelName], summary.Provider)
+		}
+	}
+
+	for _, model := range slices.Sorted(maps.Keys(providers)) {
+		if served := providers[model]; len(served) > 1 {
+			fmt.Printf("⚠️  %s is served by several providers (%s); tell them apart by provider/model\n", model, strings.Join(served, ", "))
+		}
+	}
+}
+
+// renderCharts renders the bar charts of every summary, plus the
+// throughput-over-time charts for streaming runs
+func renderCharts(summaries map[string]models.BenchmarkSummary, results map[string][]models.BenchmarkResult) string {
+	// Create chart generator with appropriate dimensions
+	chartGen := charts.NewChartGenerator(60, 15)
+	rendered := chartGen.GenerateAllCharts(summaries)
+
+	// Per-request throughput trend, when streaming results are available
+	for _, summary := range summaries {
+		if summary.IsStreaming {
+			rendered += chartGen.GenerateThroughputOverTimeCharts(results) + "\n\n"
+			break
+		}
+	}
+	return rendered
+}
+
+// Files of an --archive zip, at its root
+const (
+	archiveResultsName = "results.yaml"
+	archiveCSVName     = "requests.csv"
+	archiveChartsName  = "charts.txt"
+)
+
+// ansiPattern matches the escape sequences coloring the charts
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+// writeArchive bundles the results file, the per-request CSV and, when
+// asked, the charts as plain text into a zip
+func writeArchive(filename string, resultsFile *models.BenchmarkResultsFile, results map[string][]models.BenchmarkResult, withCharts bool) (err error) {
+	yamlData, err := storage.MarshalResults(resultsFile)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to close archive: %w", closeErr)
+		}
+		// Never leave a partial archive behind
+		if err != nil {
+			os.Remove(filename)
+		}
+	}()
+
+	archive := zip.NewWriter(file)
+	entry, err := archive.Create(archiveResultsName)
+	if err != nil {
+		return fmt.Errorf("failed to add %s to archive: %w", archiveResultsName, err)
+	}
+	if _, err := entry.Write(yamlData); err != nil {
+		return fmt.Errorf("failed to add %s to archive: %w", archiveResultsName, err)
+	}
+
+	entry, err = archive.Create(archiveCSVName)
+	if err != nil {
+		return fmt.Errorf("failed to add %s to archive: %w", archiveCSVName, err)
+	}
+	if err := writeCSVResults(entry, results); err != nil {
+		return err
+	}
+
+	if withCharts {
+		entry, err = archive.Create(archiveChartsName)
+		if err != nil {
+			return fmt.Errorf("failed to add %s to archive: %w", archiveChartsName, err)
+		}
+		chartsText := ansiPattern.ReplaceAllString(renderCharts(resultsFile.Summaries, results), "")
+		if _, err := io.WriteString(entry, chartsText); err != nil {
+			return fmt.Errorf("failed to add %s to archive: %w", archiveChartsName, err)
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	return nil
+}
+
+// influxTagKeys are the tags every --influx line carries, renamed with
+// --influx-tag-keys
+var influxTagKeys = []string{"provider", "model"}
+
+// influxEscaper escapes the characters delimiting line protocol measurements,
+// tag keys and tag values
+var influxEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+// writeInflux writes the summaries, or every request with perRequest, in
+// InfluxDB line protocol. Summary lines are timestamped with the run's time
+// and request lines with their start, so they don't overwrite each other
+func writeInflux(filename, measurement string, tagKeys map[string]string, timestamp time.Time, summaries map[string]models.BenchmarkSummary, results map[string][]models.BenchmarkResult, perRequest bool) error {
+	providerKey, modelKey := "provider", "model"
+	if renamed, ok := tagKeys["provider"]; ok {
+		providerKey = renamed
+	}
+	if renamed, ok := tagKeys["model"]; ok {
+		modelKey = renamed
+	}
+	tags := func(provider, model string) string {
+		line := influxEscaper.Replace(measurement) + "," + influxEscaper.Replace(providerKey) + "=" + influxEscaper.Replace(provider)
+		// Influx rejects empty tag values, and single-model runs may have none
+		if model != "" {
+			line += "," + influxEscaper.Replace(modelKey) + "=" + influxEscaper.Replace(model)
+		}
+		return line
+	}
+
+	var b strings.Builder
+	if perRequest {
+		for _, key := range slices.Sorted(maps.Keys(results)) {
+			for _, result := range results[key] {
+				fields := []string{
+					fmt.Sprintf("success=%t", result.Success),
+					"response_time_ms=" + formatMillis(result.ResponseTime),
+					fmt.Sprintf("input_tokens=%di", result.InputTokens),
+					fmt.Sprintf("output_tokens=%di", result.OutputTokens),
+				}
+				if result.TimeToFirstToken > 0 {
+					fields = append(fields, "ttft_ms="+formatMillis(result.TimeToFirstToken))
+				}
+				if result.TokenThroughput > 0 {
+					fields = append(fields, "token_throughput="+strconv.FormatFloat(result.TokenThroughput, 'f', -1, 64))
+				}
+				fmt.Fprintf(&b, "%s %s %d\n", tags(result.Provider, result.ModelName), strings.Join(fields, ","), result.StartedAt.UnixNano())
+			}
+		}
+	} else {
+		for _, key := range sortedSummaryKeys(summaries) {
+			summary := summaries[key]
+			fields := []string{
+				fmt.Sprintf("total_requests=%di", summary.TotalRequests),
+				fmt.Sprintf("successful_requests=%di", summary.SuccessfulReqs),
+				fmt.Sprintf("failed_requests=%di", summary.FailedRequests),
+				"error_rate=" + strconv.FormatFloat(summary.ErrorRate, 'f', -1, 64),
+				"avg_response_time_ms=" + formatMillis(summary.AvgResponseTime),
+				"p50_response_time_ms=" + formatMillis(summary.P50ResponseTime),
+				"p95_response_time_ms=" + formatMillis(summary.P95ResponseTime),
+				"p99_response_time_ms=" + formatMillis(summary.P99ResponseTime),
+				fmt.Sprintf("total_tokens=%di", summary.TotalTokens),
+			}
+			if summary.IsStreaming {
+				fields = append(fields,
+					"avg_ttft_ms="+formatMillis(summary.AvgTimeToFirstToken),
+					"avg_token_throughput="+strconv.FormatFloat(summary.AvgTokenThroughput, 'f', -1, 64),
+				)
+			}
+			fmt.Fprintf(&b, "%s %s %d\n", tags(summary.Provider, summary.ModelName), strings.Join(fields, ","), timestamp.UnixNano())
+		}
+	}
+
+	if err := os.WriteFile(filename, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filename, err)
+	}
+	return nil
+}
+
+// printInterArrival prints the measured gaps between request starts
+func printInterArrival(stats *models.InterArrivalStats) {
+	if stats == nil {
+		return
+	}
+
+	fmt.Println("\n📬 ARRIVALS")
+	fmt.Println(strings.Repeat("-", 20))
+	fmt.Printf("Rate:               %.2f/sec observed (target %.2f/sec)\n", stats.ObservedRate, stats.TargetRate)
+	fmt.Printf("Inter-arrival:      mean %s, std dev %s\n", units.Duration(stats.Mean), units.Duration(stats.StdDev))
+	fmt.Printf("Variation (CV):     %.2f (1.00 for a Poisson process)\n", stats.CV)
+}
+
+// printTraceStats prints how the replayed requests kept up with their trace
+// entries, and the entries that were slowest or failed
+func printTraceStats(stats *models.TraceStats) {
+	if stats == nil {
+		return
+	}
+
+	fmt.Println("\n📼 TRACE REPLAY")
+	fmt.Println(strings.Repeat("-", 20))
+	fmt.Printf("Entries:            %d\n", stats.Entries)
+	fmt.Printf("Start lag:          avg %s, max %s\n", units.Duration(stats.AvgLag), units.Duration(stats.MaxLag))
+	for _, entry := range stats.Slowest {
+		fmt.Printf("  #%-5d @%-10s %s\n", entry.Entry, units.Duration(entry.Offset), units.Duration(entry.ResponseTime))
+	}
+	if len(stats.Failed) > 0 {
+		failed := make([]string, len(stats.Failed))
+		for i, entry := range stats.Failed {
+			failed[i] = "#" + strconv.Itoa(entry)
+		}
+		fmt.Printf("Failed entries:     %s\n", strings.Join(failed, ", "))
+	}
+}
+
+// printOutliers lists the requests flagged as outliers, per provider/model
+func printOutliers(results map[string][]models.BenchmarkResult) {
+	fmt.Println("\n🐢 OUTLIERS (over 3 std devs from the provider/model mean)")
+	fmt.Println(strings.Repeat("-", 20))
+
+	found := false
+	for _, key := range slices.Sorted(maps.Keys(results)) {
+		for _, result := range results[key] {
+			if !result.IsOutlier {
+				continue
+			}
+			found = true
+			fmt.Printf("%-40s #%-5d %s  %s\n", key, result.Index, result.StartedAt.Format(time.RFC3339), units.Duration(result.ResponseTime))
+		}
+	}
+	if !found {
+		fmt.Println("No outliers")
+	}
+}
+
+// printTurnStats prints the latency breakdown by conversation turn depth
+func printTurnStats(stats []models.TurnStats) {
+	if len(stats) == 0 {
+		return
+	}
+
+	fmt.Println("\n💬 LATENCY BY TURN")
+	fmt.Println(strings.Repeat("-", 20))
+	for _, turn := range stats {
+		fmt.Printf("Turn %-3d avg %s, p95 %s (%d requests)\n", turn.Turn, units.Duration(turn.AvgResponseTime), units.Duration(turn.P95ResponseTime), turn.Requests)
+	}
+}
+
+// printKeyStats prints the error rate of every API key, to spot a bad one
+func printKeyStats(stats []models.KeyStats) {
+	if len(stats) == 0 {
+		return
+	}
+
+	fmt.Println("\n🔑 ERRORS BY API KEY")
+	fmt.Println(strings.Repeat("-", 20))
+	for _, key := range stats {
+		fmt.Printf("Key %-3d %.2f%% errors (%d/%d requests)\n", key.Key, key.ErrorRate, key.Failed, key.Requests)
+	}
+}
+
+// writeCSVResults writes one row per request, for analysis in spreadsheets
+// or other tools
+func writeCSVResults(w io.Writer, results map[string][]models.BenchmarkResult) error {
+	keys := make([]string, 0, len(results))
+	for key := range results {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	writer := csv.NewWriter(w)
+	header := []string{
+		"key", "provider", "model", "index", "prompt_index", "turn", "started_at",
+		"success", "response_time_ms", "tokens_used", "input_tokens", "output_tokens", "cached_input_tokens", "reasoning_tokens", "time_to_first_token_ms",
+		"token_throughput", "streaming_tokens", "response_bytes", "byte_throughput", "dns_ms", "connect_ms", "tls_ms", "ttfb_ms",
+		"trace_offset_ms", "api_key_index", "finish_reason", "logprobs", "choices", "tokens_estimated", "outlier", "request_id",
+		"ratelimit_remaining_requests", "ratelimit_remaining_tokens", "error",
+	}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	for _, key := range keys {
+		for _, result := range results[key] {
+			record := []string{
+				key,
+				result.Provider,
+				result.ModelName,
+				strconv.Itoa(result.Index),
+				strconv.Itoa(result.PromptIndex),
+				strconv.Itoa(result.Turn),
+				result.StartedAt.Format(time.RFC3339Nano),
+				strconv.FormatBool(result.Success),
+				formatMillis(result.ResponseTime),
+				strconv.Itoa(result.TokensUsed),
+				strconv.Itoa(result.InputTokens),
+				strconv.Itoa(result.OutputTokens),
+				strconv.Itoa(result.CachedInputTokens),
+				strconv.Itoa(result.ReasoningTokens),
+				formatMillis(result.TimeToFirstToken),
+				strconv.FormatFloat(result.TokenThroughput, 'f', 2, 64),
+				strconv.Itoa(result.StreamingTokens),
+				strconv.Itoa(result.ResponseBytes),
+				strconv.FormatFloat(result.ByteThroughput, 'f', 2, 64),
+				formatMillis(result.DNSTime),
+				formatMillis(result.ConnectTime),
+				formatMillis(result.TLSTime),
+				formatMillis(result.TTFB),
+				formatMillis(result.TraceOffset),
+				strconv.Itoa(result.KeyIndex),
+				result.FinishReason,
+				strconv.FormatBool(result.Logprobs),
+				strconv.Itoa(max(result.Choices, 1)),
+				strconv.FormatBool(result.TokensEstimated),
+				strconv.FormatBool(result.IsOutlier),
+				requestID(result.ResponseHeaders),
+				result.ResponseHeaders["x-ratelimit-remaining-requests"],
+				result.ResponseHeaders["x-ratelimit-remaining-tokens"],
+				result.Error,
+			}
+			if err := writer.Write(record); err != nil {
+				return fmt.Errorf("failed to write CSV record: %w", err)
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// requestID returns the provider's request id from the recorded headers
+func requestID(headers map[string]string) string {
+	if id := headers["x-request-id"]; id != "" {
+		return id
+	}
+	return headers["request-id"]
+}
+
+// writeMarkdownResults writes the summaries as a Markdown table
+func writeMarkdownResults(w io.Writer, summaries map[string]models.BenchmarkSummary) error {
+	var b strings.Builder
+	b.WriteString("| Provider/Model | Requests | Error Rate | Avg | P50 | P95 | P99 | Avg TTFT | Avg Throughput |\n")
+	b.WriteString("|---|---:|---:|---:|---:|---:|---:|---:|---:|\n")
+
+	for _, key := range sortedSummaryKeys(summaries) {
+		summary := summaries[key]
+		ttft, throughput := streamingColumns(summary)
+		fmt.Fprintf(&b, "| %s | %d | %.2f%% | %s | %s | %s | %s | %s | %s |\n",
+			strings.ReplaceAll(key, "|", "\\|"), summary.TotalRequests, summary.ErrorRate,
+			units.Duration(summary.AvgResponseTime), units.Duration(summary.P50ResponseTime), units.Duration(summary.P95ResponseTime), units.Duration(summary.P99ResponseTime),
+			ttft, throughput)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeHTMLResults writes the summaries as a standalone HTML page
+func writeHTMLResults(w io.Writer, summaries map[string]models.BenchmarkSummary) error {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>LLMBench Results</title>\n")
+	b.WriteString("<style>table{border-collapse:collapse}th,td{border:1px solid #ccc;padding:4px 8px;text-align:right}th:first-child,td:first-child{text-align:left}</style>\n")
+	b.WriteString("</head>\n<body>\n<h1>LLMBench Results</h1>\n<table>\n")
+	b.WriteString("<tr><th>Provider/Model</th><th>Requests</th><th>Error Rate</th><th>Avg</th><th>P50</th><th>P95</th><th>P99</th><th>Avg TTFT</th><th>Avg Throughput</th></tr>\n")
+
+	for _, key := range sortedSummaryKeys(summaries) {
+		summary := summaries[key]
+		ttft, throughput := streamingColumns(summary)
+		fmt.Fprintf(&b, "<tr><td>%s</td><td>%d</td><td>%.2f%%</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>\n",
+			html.EscapeString(key), summary.TotalRequests, summary.ErrorRate,
+			units.Duration(summary.AvgResponseTime), units.Duration(summary.P50ResponseTime), units.Duration(summary.P95ResponseTime), units.Duration(summary.P99ResponseTime),
+			ttft, throughput)
+	}
+
+	b.WriteString("</table>\n</body>\n</html>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// streamingColumns formats the TTFT and throughput table cells, which are
+// only meaningful for streaming runs
+func streamingColumns(summary models.BenchmarkSummary) (string, string) {
+	if !summary.IsStreaming {
+		return "-", "-"
+	}
+	return units.Duration(summary.AvgTimeToFirstToken), fmt.Sprintf("%.2f tok/s", summary.AvgTokenThroughput)
+}
+
+// formatMillis formats a duration as fractional milliseconds
+func formatMillis(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64)
+}
+
+// writeMatrixResults prints a matrix run to stdout in the given format
+func writeMatrixResults(format string, matrix *models.MatrixResults) error {
+	if format == formatJSON {
+		if summaryOnly {
+			matrix = matrix.WithoutResults()
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(matrix)
+	}
+
+	fmt.Println("\n" + strings.Repeat("=", 80))
+	fmt.Println("MATRIX RESULTS")
+	fmt.Println(strings.Repeat("=", 80))
+
+	for _, key := range matrix.Keys() {
+		fmt.Printf("\n📊 %s\n", key)
+		fmt.Println(strings.Repeat("-", 50))
+
+		printMatrixGrid(matrix, key, "Avg Response Time", func(summary models.BenchmarkSummary) string {
+			return summary.AvgResponseTime.Round(time.Millisecond).String()
+		})
+
+		streaming := false
+		for _, cell := range matrix.Cells {
+			streaming = streaming || cell.Summaries[key].IsStreaming
+		}
+		if streaming {
+			printMatrixGrid(matrix, key, "Throughput (tokens/sec)", func(summary models.BenchmarkSummary) string {
+				return strconv.FormatFloat(summary.OverallTokenThroughput, 'f', 1, 64)
+			})
+		}
+	}
+
+	fmt.Println("\n" + strings.Repeat("=", 80))
+	return nil
+}
+
+// writeRepeatedResults prints every metric's mean and 95% confidence
+// interval per provider/model over repeated runs, pointing out the
+// differences that aren't significant
+func writeRepeatedResults(format string, runs int, metrics []service.RepeatedMetric) error {
+	if format == formatJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(struct {
+			Runs    int                      `json:"runs"`
+			Metrics []service.RepeatedMetric `json:"metrics"`
+		}{runs, metrics})
+	}
+
+	fmt.Println("\n" + strings.Repeat("=", 80))
+	fmt.Printf("REPEATED RUNS (%d runs, mean ± 95%% confidence interval)\n", runs)
+	fmt.Println(strings.Repeat("=", 80))
+
+	for _, metric := range metrics {
+		fmt.Printf("\n📊 %s\n", metric.Name)
+		fmt.Println(strings.Repeat("-", 50))
+
+		keys := slices.Sorted(maps.Keys(metric.Intervals))
+		width := 0
+		for _, key := range keys {
+			width = max(width, len(key))
+		}
+		for _, key := range keys {
+			interval := metric.Intervals[key]
+			fmt.Printf("  %-*s  %s ± %s\n", width, key, formatMetric(metric, interval.Mean), formatMetric(metric, interval.HalfWidth))
+		}
+
+		if pairs := metric.Overlapping(); len(pairs) > 0 {
+			overlapping := make([]string, len(pairs))
+			for i, pair := range pairs {
+				overlapping[i] = pair[0] + " vs " + pair[1]
+			}
+			fmt.Printf("  ≈ Intervals overlap, difference not significant: %s\n", strings.Join(overlapping, ", "))
+		}
+	}
+
+	fmt.Println("\n" + strings.Repeat("=", 80))
+	return nil
+}
+
+// formatMetric formats a value of a repeated metric in its unit
+func formatMetric(metric service.RepeatedMetric, value float64) string {
+	switch {
+	case metric.Duration:
+		return units.Duration(time.Duration(value))
+	case metric.Unit == "%":
+		return units.Float(value, 2) + "%"
+	default:
+		return units.Float(value, 2) + " " + metric.Unit
+	}
+}
+
+// printMatrixGrid prints one metric of a provider/model as a grid with a
+// row per concurrency and a column per max tokens value
+func printMatrixGrid(matrix *models.MatrixResults, key, title string, value func(models.BenchmarkSummary) string) {
+	fmt.Printf("\n%s (rows: concurrency, columns: max tokens)\n", title)
+
+	fmt.Printf("%12s", "")
+	for _, maxTokens := range matrix.MaxTokens {
+		fmt.Printf("%12d", maxTokens)
+	}
+	fmt.Println()
+
+	for _, concurrency := range matrix.Concurrency {
+		fmt.Printf("%12d", concurrency)
+		for _, maxTokens := range matrix.MaxTokens {
+			text := "-"
+			if cell := matrix.Cell(concurrency, maxTokens); cell != nil {
+				if summary, ok := cell.Summaries[key]; ok {
+					text = value(summary)
+				}
+			}
+			fmt.Printf("%12s", text)
+		}
+		fmt.Println()
+	}
+}
+
+// filterMatrixByProvider keeps the provider/models of every matrix cell
+// that match one of the patterns; no patterns keeps everything
+func filterMatrixByProvider(matrix *models.MatrixResults, patterns []string) *models.MatrixResults {
+	if len(patterns) == 0 {
+		return matrix
+	}
+
+	filtered := &models.MatrixResults{
+		Concurrency: matrix.Concurrency,
+		MaxTokens:   matrix.MaxTokens,
+	}
+	for _, cell := range matrix.Cells {
+		cell.Summaries, cell.Results = filterByProvider(cell.Summaries, cell.Results, patterns)
+		filtered.Cells = append(filtered.Cells, cell)
+	}
+	return filtered
+}
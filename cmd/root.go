@@ -4,14 +4,22 @@ import (
 	"fmt"
 	"os"
 
+	"llmbench/internal/charts"
 	"llmbench/internal/config"
+	"llmbench/internal/service"
+	"llmbench/internal/units"
 
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 var (
 	cfgFile   string
+	profile   string
+	noColor   bool
+	unitsFlag string
 	configMgr *config.Manager
 	rootCmd   = &cobra.Command{
 		Use:   "llmbench",
@@ -35,7 +43,10 @@ func init() {
 
 	// Global flags
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.config/llmbench/llmbench.yaml)")
-	rootCmd.PersistentFlags().Bool("verbose", false, "verbose output")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "config profile to load over the top-level settings (default from LLMBENCH_PROFILE)")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "disable colored output (also disabled when NO_COLOR is set)")
+	rootCmd.PersistentFlags().StringVar(&unitsFlag, "units", units.Auto, "units for latencies in human-readable output: ms, s or auto")
+	rootCmd.PersistentFlags().Bool("verbose", false, "verbose output (logs each request, retries and provider errors to stderr)")
 
 	// Bind flags to viper
 	viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
@@ -44,6 +55,18 @@ func init() {
 // initConfig reads in config file and ENV variables.
 func initConfig() {
 	configMgr = config.NewManager()
+	if profile == "" {
+		profile = os.Getenv("LLMBENCH_PROFILE")
+	}
+	configMgr.SetProfile(profile)
+	service.SetVerbose(viper.GetBool("verbose"))
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+	if err := units.Set(unitsFlag); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 	
 	// Skip config loading for config init command to avoid chicken-and-egg problem
 	if len(os.Args) >= 3 && os.Args[1] == "config" && os.Args[2] == "init" {
@@ -54,4 +77,6 @@ func initConfig() {
 		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
 		os.Exit(1)
 	}
+	charts.SetPalette(configMgr.GetConfig().Charts.Colors)
+	charts.SetTTFTPercentiles(configMgr.GetConfig().Charts.TTFTPercentiles)
 }
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"llmbench/internal/server"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveCmd = &cobra.Command{
+		Use:   "serve",
+		Short: "Run benchmarks on demand over HTTP",
+		Long: `Start an HTTP server that runs benchmarks on request.
+POST /benchmark with a JSON body describing the benchmark request and
+optional requests, concurrency and providers overrides; the response holds
+the summaries as JSON. Only one benchmark runs at a time, others are
+rejected with 429 Too Many Requests.`,
+		RunE: runServe,
+	}
+
+	// Serve flags
+	serveAddr string
+)
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().StringVar(&serveAddr, "addr", ":8080", "Address to listen on")
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	config := configMgr.GetBenchmarkConfig()
+
+	httpServer := &http.Server{
+		Addr:              serveAddr,
+		Handler:           server.New(config).Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	fmt.Printf("🚀 Listening on %s (POST /benchmark)\n", serveAddr)
+	if err := httpServer.ListenAndServe(); err != nil {
+		return fmt.Errorf("server failed: %w", err)
+	}
+
+	return nil
+}
@@ -2,7 +2,12 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"llmbench/internal/service"
@@ -17,48 +22,125 @@ This command sends a simple test message to verify that the providers
 are reachable and responding correctly.`,
 		RunE: runTest,
 	}
+
+	// Test flags
+	checkModels bool
+	testJSON    bool
+	testTags    []string
 )
 
+// connectionStatus is the JSON representation of a provider's connection test
+type connectionStatus struct {
+	Connected bool    `json:"connected"`
+	Error     string  `json:"error"`
+	LatencyMs float64 `json:"latency_ms"`
+}
+
 func init() {
 	rootCmd.AddCommand(testCmd)
+
+	testCmd.Flags().BoolVar(&checkModels, "check-models", false, "Verify configured models are listed by the provider's /models endpoint")
+	testCmd.Flags().BoolVar(&testJSON, "json", false, "Output results as JSON, keyed by provider")
+	testCmd.Flags().StringSliceVar(&testTags, "tag", nil, "Only test providers with this tag; repeatable, matching any of the tags")
 }
 
 func runTest(cmd *cobra.Command, args []string) error {
 	config := configMgr.GetBenchmarkConfig()
 
+	if len(testTags) > 0 {
+		config.Providers = filterProvidersByTag(config.Providers, testTags)
+		if len(config.Providers) == 0 {
+			return fmt.Errorf("no configured providers are tagged %s", strings.Join(testTags, ", "))
+		}
+	}
+
 	// Create benchmark service
 	benchmarkService, err := service.NewBenchmarkService(config)
 	if err != nil {
 		return fmt.Errorf("failed to create benchmark service: %w", err)
 	}
+	defer benchmarkService.Close()
 
-	fmt.Println("Testing connections to configured providers...")
-	fmt.Println()
+	if !testJSON {
+		fmt.Println("Testing connections to configured providers...")
+		fmt.Println()
+	}
 
 	ctx := context.Background()
-	results := benchmarkService.TestConnections(ctx)
+	results := benchmarkService.CheckConnections(ctx)
+
+	// Model availability failures are more actionable than a generic
+	// completion failure, so they take precedence
+	if checkModels {
+		for provider, err := range benchmarkService.VerifyModels(ctx) {
+			if err != nil {
+				result := results[provider]
+				result.Err = err
+				results[provider] = result
+			}
+		}
+	}
 
 	successCount := 0
 	totalCount := len(results)
-
-	for provider, err := range results {
-		if err != nil {
-			fmt.Printf("❌ %s: %v\n", provider, err)
-		} else {
-			fmt.Printf("✅ %s: Connection successful\n", provider)
+	for _, result := range results {
+		if result.Err == nil {
 			successCount++
 		}
 	}
 
-	fmt.Println()
-	fmt.Printf("Results: %d/%d providers connected successfully\n", successCount, totalCount)
-
-	if successCount == totalCount {
-		fmt.Println("🎉 All providers are ready for benchmarking!")
+	if testJSON {
+		if err := outputTestJSON(results); err != nil {
+			return err
+		}
 	} else {
-		fmt.Println("⚠️  Some providers failed connection test. Check your configuration.")
+		providers := make([]string, 0, len(results))
+		for provider := range results {
+			providers = append(providers, provider)
+		}
+		sort.Strings(providers)
+
+		for _, provider := range providers {
+			result := results[provider]
+			if result.Err != nil {
+				fmt.Printf("❌ %s: %v\n", provider, result.Err)
+			} else {
+				fmt.Printf("✅ %s: Connection successful (%v)\n", provider, result.Latency.Round(time.Millisecond))
+			}
+		}
+
+		fmt.Println()
+		fmt.Printf("Results: %d/%d providers connected successfully\n", successCount, totalCount)
+
+		if successCount == totalCount {
+			fmt.Println("🎉 All providers are ready for benchmarking!")
+		} else {
+			fmt.Println("⚠️  Some providers failed connection test. Check your configuration.")
+		}
+	}
+
+	if successCount < totalCount {
 		return fmt.Errorf("connection test failed for %d provider(s)", totalCount-successCount)
 	}
 
 	return nil
 }
+
+// outputTestJSON prints connection test results as JSON, keyed by provider
+func outputTestJSON(results map[string]service.ConnectionResult) error {
+	output := make(map[string]connectionStatus, len(results))
+	for provider, result := range results {
+		status := connectionStatus{
+			Connected: result.Err == nil,
+			LatencyMs: float64(result.Latency.Microseconds()) / 1000,
+		}
+		if result.Err != nil {
+			status.Error = result.Err.Error()
+		}
+		output[provider] = status
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(output)
+}
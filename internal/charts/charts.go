@@ -8,6 +8,8 @@ import (
 	"llmbench/internal/models"
 
 	"github.com/NimbleMarkets/ntcharts/barchart"
+	"github.com/NimbleMarkets/ntcharts/canvas"
+	"github.com/NimbleMarkets/ntcharts/linechart"
 	"github.com/charmbracelet/lipgloss"
 )
 
@@ -17,8 +19,34 @@ type ChartGenerator struct {
 	height int
 }
 
+// palette overrides the default chart colors when set
+var palette []lipgloss.AdaptiveColor
+
+// SetPalette replaces the default chart colors with the given ones (hex
+// codes or ANSI color numbers), used for both light and dark terminals
+func SetPalette(colors []string) {
+	palette = nil
+	for _, color := range colors {
+		palette = append(palette, lipgloss.AdaptiveColor{Light: color, Dark: color})
+	}
+}
+
+// ttftPercentiles groups p50/p90/p99 bars per provider/model in the TTFT
+// chart instead of a single average bar
+var ttftPercentiles bool
+
+// SetTTFTPercentiles selects whether the TTFT chart shows p50/p90/p99 bars
+// per provider/model rather than their average, to reveal tail behavior
+func SetTTFTPercentiles(enabled bool) {
+	ttftPercentiles = enabled
+}
+
 // getAdaptiveColors returns theme-adaptive colors for charts
 func (cg *ChartGenerator) getAdaptiveColors() []lipgloss.AdaptiveColor {
+	if len(palette) > 0 {
+		return palette
+	}
+
 	return []lipgloss.AdaptiveColor{
 		{Light: "#22C55E", Dark: "#10B981"}, // Green
 		{Light: "#EF4444", Dark: "#F87171"}, // Red  
@@ -120,6 +148,9 @@ func (cg *ChartGenerator) GenerateTTFTChart(summaries map[string]models.Benchmar
 	}
 	
 	sort.Strings(validKeys) // Ensure consistent ordering
+	if ttftPercentiles {
+		return cg.generateTTFTPercentileChart(validKeys, summaries)
+	}
 
 	var barData []barchart.BarData
 	var legendEntries []LegendEntry
@@ -163,6 +194,62 @@ func (cg *ChartGenerator) GenerateTTFTChart(summaries map[string]models.Benchmar
 	return result
 }
 
+// generateTTFTPercentileChart groups a p50, p90 and p99 TTFT bar per
+// provider/model, in its color, with a blank bar between groups and the
+// key on the first bar of each group
+func (cg *ChartGenerator) generateTTFTPercentileChart(keys []string, summaries map[string]models.BenchmarkSummary) string {
+	var barData []barchart.BarData
+	var legend strings.Builder
+	adaptiveColors := cg.getAdaptiveColors()
+
+	legend.WriteString("\n📋 TTFT Percentiles Legend:\n")
+	legend.WriteString(strings.Repeat("─", cg.width) + "\n")
+	maxLabelLen := 0
+	for _, key := range keys {
+		maxLabelLen = max(maxLabelLen, len(key))
+	}
+
+	for i, key := range keys {
+		summary := summaries[key]
+		adaptiveColor := adaptiveColors[i%len(adaptiveColors)]
+		style := lipgloss.NewStyle().Foreground(adaptiveColor)
+
+		if i > 0 {
+			barData = append(barData, barchart.BarData{})
+		}
+		percentiles := []struct {
+			name  string
+			value float64
+		}{
+			{"p50", float64(summary.P50TimeToFirstToken.Nanoseconds()) / 1e6},
+			{"p90", float64(summary.P90TimeToFirstToken.Nanoseconds()) / 1e6},
+			{"p99", float64(summary.P99TimeToFirstToken.Nanoseconds()) / 1e6},
+		}
+		for j, p := range percentiles {
+			// Name the group on its first bar so it doesn't rely on color
+			label := p.name
+			if j == 0 {
+				label = key + " " + p.name
+			}
+			barData = append(barData, barchart.BarData{
+				Label:  label,
+				Values: []barchart.BarValue{{Name: p.name, Value: p.value, Style: style}},
+			})
+		}
+
+		indicator := lipgloss.NewStyle().Foreground(lipgloss.Color(adaptiveColor.Dark)).Render("■")
+		legend.WriteString(fmt.Sprintf("  %s %-*s: p50 %.1f, p90 %.1f, p99 %.1f ms\n",
+			indicator, maxLabelLen, key, percentiles[0].value, percentiles[1].value, percentiles[2].value))
+	}
+
+	bc := barchart.New(cg.width, cg.height)
+	bc.PushAll(barData)
+	bc.Draw()
+
+	return fmt.Sprintf("📊 Time to First Token Percentiles (ms)\n%s\n%s%s",
+		strings.Repeat("─", cg.width), bc.View(), legend.String())
+}
+
 // GenerateThroughputChart creates a bar chart showing token throughput for each model
 func (cg *ChartGenerator) GenerateThroughputChart(summaries map[string]models.BenchmarkSummary) string {
 	if len(summaries) == 0 {
@@ -284,6 +371,90 @@ func (cg *ChartGenerator) GenerateResponseTimeChart(summaries map[string]models.
 	return result
 }
 
+// GenerateThroughputOverTimeChart creates a line chart of per-request token
+// throughput in completion order for a single provider/model
+func (cg *ChartGenerator) GenerateThroughputOverTimeChart(key string, results []models.BenchmarkResult) string {
+	// Keep only requests that produced a throughput measurement
+	var points []models.BenchmarkResult
+	for _, result := range results {
+		if result.Success && result.IsStreaming && result.TokenThroughput > 0 {
+			points = append(points, result)
+		}
+	}
+
+	if len(points) < 2 {
+		return fmt.Sprintf("Not enough streaming data for %s throughput over time chart", key)
+	}
+
+	// Order by completion time, falling back to the request index so that
+	// the ordering is deterministic despite concurrency
+	sort.Slice(points, func(i, j int) bool {
+		ci, cj := points[i].CompletedAt(), points[j].CompletedAt()
+		if !ci.Equal(cj) {
+			return ci.Before(cj)
+		}
+		return points[i].Index < points[j].Index
+	})
+
+	maxThroughput := 0.0
+	for _, point := range points {
+		if point.TokenThroughput > maxThroughput {
+			maxThroughput = point.TokenThroughput
+		}
+	}
+
+	adaptiveColors := cg.getAdaptiveColors()
+	lineStyle := lipgloss.NewStyle().Foreground(adaptiveColors[3])
+
+	lc := linechart.New(cg.width, cg.height, 1, float64(len(points)), 0, maxThroughput*1.1,
+		linechart.WithXYSteps(4, 2))
+	lc.DrawXYAxisAndLabel()
+	for i := 1; i < len(points); i++ {
+		lc.DrawBrailleLineWithStyle(
+			canvas.Float64Point{X: float64(i), Y: points[i-1].TokenThroughput},
+			canvas.Float64Point{X: float64(i + 1), Y: points[i].TokenThroughput},
+			lineStyle,
+		)
+	}
+
+	first := points[0].TokenThroughput
+	last := points[len(points)-1].TokenThroughput
+
+	result := fmt.Sprintf("📈 Throughput Over Time - %s (tokens/sec by completion order)\n%s\n%s",
+		key, strings.Repeat("─", cg.width), lc.View())
+	result += fmt.Sprintf("\n  First: %.1f tokens/sec, Last: %.1f tokens/sec, Peak: %.1f tokens/sec\n",
+		first, last, maxThroughput)
+
+	return result
+}
+
+// GenerateThroughputOverTimeCharts creates throughput over time charts for
+// every provider/model with streaming results
+func (cg *ChartGenerator) GenerateThroughputOverTimeCharts(results map[string][]models.BenchmarkResult) string {
+	var keys []string
+	for key, providerResults := range results {
+		for _, result := range providerResults {
+			if result.IsStreaming {
+				keys = append(keys, key)
+				break
+			}
+		}
+	}
+
+	if len(keys) == 0 {
+		return "No streaming data available for throughput over time chart"
+	}
+
+	sort.Strings(keys) // Ensure consistent ordering
+
+	var charts []string
+	for _, key := range keys {
+		charts = append(charts, cg.GenerateThroughputOverTimeChart(key, results[key]))
+	}
+
+	return strings.Join(charts, "\n\n")
+}
+
 // GenerateAllCharts generates all available charts for the given summaries
 func (cg *ChartGenerator) GenerateAllCharts(summaries map[string]models.BenchmarkSummary) string {
 	var result string
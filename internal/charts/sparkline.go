@@ -0,0 +1,73 @@
+package charts
+
+import (
+	"sort"
+	"strings"
+
+	"llmbench/internal/models"
+)
+
+// sparkTicks are the bar heights of a sparkline, from lowest to highest
+var sparkTicks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders values as a line of bars no wider than width; longer
+// series are split into width buckets, each showing its average
+func Sparkline(values []float64, width int) string {
+	if len(values) == 0 || width <= 0 {
+		return ""
+	}
+
+	if len(values) > width {
+		buckets := make([]float64, width)
+		for i := range buckets {
+			start, end := i*len(values)/width, (i+1)*len(values)/width
+			sum := 0.0
+			for _, v := range values[start:end] {
+				sum += v
+			}
+			buckets[i] = sum / float64(end-start)
+		}
+		values = buckets
+	}
+
+	low, high := values[0], values[0]
+	for _, v := range values {
+		low, high = min(low, v), max(high, v)
+	}
+
+	var line strings.Builder
+	for _, v := range values {
+		tick := 0
+		if high > low {
+			tick = int((v - low) / (high - low) * float64(len(sparkTicks)-1))
+		}
+		line.WriteRune(sparkTicks[tick])
+	}
+	return line.String()
+}
+
+// LatencySparkline renders the response time of every successful request in
+// completion order, falling back to the request index like the throughput
+// over time chart
+func LatencySparkline(results []models.BenchmarkResult, width int) string {
+	var completed []models.BenchmarkResult
+	for _, result := range results {
+		if result.Success {
+			completed = append(completed, result)
+		}
+	}
+
+	sort.Slice(completed, func(i, j int) bool {
+		ci, cj := completed[i].CompletedAt(), completed[j].CompletedAt()
+		if !ci.Equal(cj) {
+			return ci.Before(cj)
+		}
+		return completed[i].Index < completed[j].Index
+	})
+
+	values := make([]float64, len(completed))
+	for i, result := range completed {
+		values[i] = float64(result.ResponseTime)
+	}
+	return Sparkline(values, width)
+}
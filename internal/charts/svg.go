@@ -0,0 +1,130 @@
+package charts
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"llmbench/internal/models"
+)
+
+// SVG layout, in pixels
+const (
+	svgWidth       = 800
+	svgLabelWidth  = 240
+	svgValueWidth  = 110
+	svgBarHeight   = 22
+	svgBarGap      = 8
+	svgTitleHeight = 40
+	svgChartGap    = 24
+)
+
+// svgChart is one bar chart of an SVG image
+type svgChart struct {
+	title  string
+	unit   string
+	value  func(models.BenchmarkSummary) float64
+	format string // value format, e.g. "%.1f"
+}
+
+// SVG renders the same bar charts as GenerateAllCharts as a standalone SVG
+// image, for embedding in documents: average response time, plus time to
+// first token and throughput when the run streamed. Bars are labeled with
+// the provider/model key and their value
+func SVG(summaries map[string]models.BenchmarkSummary) string {
+	charts := []svgChart{{
+		title:  "Average Response Time",
+		unit:   "ms",
+		value:  func(s models.BenchmarkSummary) float64 { return float64(s.AvgResponseTime.Nanoseconds()) / 1e6 },
+		format: "%.1f",
+	}}
+	for _, summary := range summaries {
+		if summary.IsStreaming {
+			charts = append(charts,
+				svgChart{
+					title:  "Average Time to First Token",
+					unit:   "ms",
+					value:  func(s models.BenchmarkSummary) float64 { return float64(s.AvgTimeToFirstToken.Nanoseconds()) / 1e6 },
+					format: "%.1f",
+				},
+				svgChart{
+					title:  "Average Token Throughput",
+					unit:   "tokens/sec",
+					value:  func(s models.BenchmarkSummary) float64 { return s.AvgTokenThroughput },
+					format: "%.2f",
+				},
+			)
+			break
+		}
+	}
+
+	// Colors are picked per provider/model across all charts, as in the
+	// terminal charts, so a bar keeps its color from one chart to the next
+	keys := make([]string, 0, len(summaries))
+	for key := range summaries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	colors := make(map[string]string, len(keys))
+	for i, key := range keys {
+		colors[key] = svgColor(i)
+	}
+
+	var body strings.Builder
+	y := 0
+	for _, chart := range charts {
+		y = chart.render(&body, y, keys, summaries, colors)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="13">`+"\n",
+		svgWidth, y, svgWidth, y)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#ffffff"/>`+"\n", svgWidth, y)
+	b.WriteString(body.String())
+	b.WriteString("</svg>\n")
+	return b.String()
+}
+
+// render draws the chart's bars for the keys with a value, starting at y,
+// and returns the y the next chart starts at
+func (c svgChart) render(b *strings.Builder, y int, keys []string, summaries map[string]models.BenchmarkSummary, colors map[string]string) int {
+	var valid []string
+	maxValue := 0.0
+	for _, key := range keys {
+		if value := c.value(summaries[key]); value > 0 {
+			valid = append(valid, key)
+			maxValue = max(maxValue, value)
+		}
+	}
+
+	fmt.Fprintf(b, `<text x="10" y="%d" font-size="16" font-weight="bold">%s (%s)</text>`+"\n", y+26, html.EscapeString(c.title), html.EscapeString(c.unit))
+	y += svgTitleHeight
+	if len(valid) == 0 {
+		fmt.Fprintf(b, `<text x="10" y="%d" fill="#888888">No data available</text>`+"\n", y+16)
+		return y + svgBarHeight + svgChartGap
+	}
+
+	barSpace := float64(svgWidth - svgLabelWidth - svgValueWidth)
+	for _, key := range valid {
+		value := c.value(summaries[key])
+		barWidth := value / maxValue * barSpace
+		textY := y + svgBarHeight/2 + 5
+		fmt.Fprintf(b, `<text x="%d" y="%d" text-anchor="end">%s</text>`+"\n", svgLabelWidth-10, textY, html.EscapeString(key))
+		fmt.Fprintf(b, `<rect x="%d" y="%d" width="%.1f" height="%d" fill="%s"/>`+"\n", svgLabelWidth, y, barWidth, svgBarHeight, colors[key])
+		fmt.Fprintf(b, `<text x="%.1f" y="%d">`+c.format+`</text>`+"\n", float64(svgLabelWidth)+barWidth+6, textY, value)
+		y += svgBarHeight + svgBarGap
+	}
+	return y + svgChartGap
+}
+
+// svgColor returns the i-th chart color, light variant since images have a
+// white background. ANSI color numbers of a custom palette have no SVG
+// equivalent, so those bars are blue
+func svgColor(i int) string {
+	colors := (&ChartGenerator{}).getAdaptiveColors()
+	if color := colors[i%len(colors)].Light; strings.HasPrefix(color, "#") {
+		return color
+	}
+	return "#3B82F6"
+}
@@ -2,8 +2,14 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -12,13 +18,27 @@ import (
 
 // Config holds the application configuration
 type Config struct {
-	Benchmark models.BenchmarkConfig `mapstructure:"benchmark"`
+	Benchmark models.BenchmarkConfig `mapstructure:"benchmark" yaml:"benchmark"`
+	Charts    ChartsConfig           `mapstructure:"charts" yaml:"charts,omitempty"`
 }
 
+// ChartsConfig customizes chart rendering
+type ChartsConfig struct {
+	Colors          []string `mapstructure:"colors" yaml:"colors,omitempty"`                     // palette as hex codes (#RRGGBB) or ANSI color numbers
+	TTFTPercentiles bool     `mapstructure:"ttft_percentiles" yaml:"ttft_percentiles,omitempty"` // TTFT chart groups p50/p90/p99 bars per provider/model
+}
+
+// colorPattern matches the color formats accepted in the chart palette
+var colorPattern = regexp.MustCompile(`^(#[0-9a-fA-F]{6}|[0-9]{1,3})$`)
+
+// DefaultProfile names the top-level settings used when no profile is selected
+const DefaultProfile = "default"
+
 // Manager handles configuration loading and management
 type Manager struct {
-	config *Config
-	viper  *viper.Viper
+	config  *Config
+	viper   *viper.Viper
+	profile string
 }
 
 // NewManager creates a new configuration manager
@@ -29,6 +49,20 @@ func NewManager() *Manager {
 	}
 }
 
+// SetProfile selects the named profile to load on top of the top-level
+// settings; an empty name keeps the top-level settings alone
+func (m *Manager) SetProfile(profile string) {
+	m.profile = profile
+}
+
+// Profile returns the name of the active profile
+func (m *Manager) Profile() string {
+	if m.profile == "" {
+		return DefaultProfile
+	}
+	return m.profile
+}
+
 // Load loads configuration from file and environment variables
 func (m *Manager) Load(configPath string) error {
 	// Set default values
@@ -63,13 +97,128 @@ func (m *Manager) Load(configPath string) error {
 		// Config file not found is OK, we'll use defaults
 	}
 
+	if err := m.applyProfile(); err != nil {
+		return err
+	}
+
 	// Unmarshal into config struct
 	m.config = &Config{}
 	if err := m.viper.Unmarshal(m.config); err != nil {
 		return fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
-	return m.validate()
+	if err := m.resolveSecrets(); err != nil {
+		return err
+	}
+
+	if err := m.validate(); err != nil {
+		return err
+	}
+	m.warn()
+	return nil
+}
+
+// envPattern matches the ${VAR} environment variable references of a value
+var envPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// secretFilePrefix marks a value to be read from a file, e.g. a secret
+// mounted in a container
+const secretFilePrefix = "file:"
+
+// resolveSecrets replaces the API keys and header values of every provider
+// that point at a file or environment variables with what they point at
+func (m *Manager) resolveSecrets() error {
+	for i := range m.config.Benchmark.Providers {
+		provider := &m.config.Benchmark.Providers[i]
+
+		apiKey, err := resolveSecret(provider.APIKey)
+		if err != nil {
+			return fmt.Errorf("provider %s: api_key: %w", provider.Name, err)
+		}
+		provider.APIKey = apiKey
+
+		for j, key := range provider.APIKeys {
+			resolved, err := resolveSecret(key)
+			if err != nil {
+				return fmt.Errorf("provider %s: api_keys[%d]: %w", provider.Name, j, err)
+			}
+			provider.APIKeys[j] = resolved
+		}
+
+		if provider.TokenAuth != nil {
+			secret, err := resolveSecret(provider.TokenAuth.ClientSecret)
+			if err != nil {
+				return fmt.Errorf("provider %s: token_auth.client_secret: %w", provider.Name, err)
+			}
+			provider.TokenAuth.ClientSecret = secret
+		}
+
+		for name, value := range provider.Headers {
+			resolved, err := resolveSecret(value)
+			if err != nil {
+				return fmt.Errorf("provider %s: header %s: %w", provider.Name, name, err)
+			}
+			provider.Headers[name] = resolved
+		}
+	}
+	return nil
+}
+
+// resolveSecret returns the trimmed content of the file a "file:<path>"
+// value points at, or the value with its ${VAR} references expanded
+func resolveSecret(value string) (string, error) {
+	if path, ok := strings.CutPrefix(value, secretFilePrefix); ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret file: %w", err)
+		}
+		secret := strings.TrimSpace(string(data))
+		if secret == "" {
+			return "", fmt.Errorf("secret file %s is empty", path)
+		}
+		return secret, nil
+	}
+
+	// Only the braced form is expanded, as keys may contain a bare $
+	var missing []string
+	expanded := envPattern.ReplaceAllStringFunc(value, func(ref string) string {
+		name := envPattern.FindStringSubmatch(ref)[1]
+		env, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return env
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("environment variable %s is not set", strings.Join(missing, ", "))
+	}
+	return expanded, nil
+}
+
+// applyProfile merges the selected profile over the top-level settings, so
+// profiles only need to list what differs between environments
+func (m *Manager) applyProfile() error {
+	if m.profile == "" || m.profile == DefaultProfile {
+		return nil
+	}
+
+	key := "profiles." + m.profile
+	if !m.viper.IsSet(key) {
+		available := make([]string, 0)
+		for name := range m.viper.GetStringMap("profiles") {
+			available = append(available, name)
+		}
+		sort.Strings(available)
+		if len(available) == 0 {
+			return fmt.Errorf("profile %q not found: no profiles are configured", m.profile)
+		}
+		return fmt.Errorf("profile %q not found (available: %s)", m.profile, strings.Join(available, ", "))
+	}
+
+	if err := m.viper.MergeConfigMap(m.viper.GetStringMap(key)); err != nil {
+		return fmt.Errorf("failed to apply profile %s: %w", m.profile, err)
+	}
+	return nil
 }
 
 // setDefaults sets default configuration values
@@ -77,6 +226,7 @@ func (m *Manager) setDefaults() {
 	m.viper.SetDefault("benchmark.concurrency", 1)
 	m.viper.SetDefault("benchmark.requests", 10)
 	m.viper.SetDefault("benchmark.timeout", "30s")
+	m.viper.SetDefault("benchmark.connection_timeout", "60s")
 	m.viper.SetDefault("benchmark.providers", []models.Provider{})
 }
 
@@ -93,8 +243,94 @@ func (m *Manager) validate() error {
 		if provider.BaseURL == "" {
 			return fmt.Errorf("provider %s: base_url is required", provider.Name)
 		}
-		if provider.APIKey == "" {
-			return fmt.Errorf("provider %s: api_key is required", provider.Name)
+		if provider.APIKey == "" && len(provider.APIKeys) == 0 && provider.TokenAuth == nil {
+			return fmt.Errorf("provider %s: api_key, api_keys or token_auth is required", provider.Name)
+		}
+		if auth := provider.TokenAuth; auth != nil {
+			if (auth.Command == "") == (auth.URL == "") {
+				return fmt.Errorf("provider %s: token_auth needs exactly one of command or url", provider.Name)
+			}
+			if auth.URL != "" {
+				if _, err := url.ParseRequestURI(auth.URL); err != nil {
+					return fmt.Errorf("provider %s: invalid token_auth.url: %w", provider.Name, err)
+				}
+				if auth.ClientID == "" || auth.ClientSecret == "" {
+					return fmt.Errorf("provider %s: token_auth.url requires client_id and client_secret", provider.Name)
+				}
+			}
+			if auth.TTL < 0 {
+				return fmt.Errorf("provider %s: token_auth.ttl cannot be negative", provider.Name)
+			}
+			if len(provider.APIKeys) > 0 {
+				return fmt.Errorf("provider %s: token_auth cannot be combined with api_keys", provider.Name)
+			}
+		}
+		if slices.Contains(provider.APIKeys, "") {
+			return fmt.Errorf("provider %s: api_keys cannot be empty", provider.Name)
+		}
+		if len(provider.KeyWeights) > 0 {
+			if len(provider.KeyWeights) != len(provider.Keys()) {
+				return fmt.Errorf("provider %s: key_weights needs one weight per key (%d), got %d", provider.Name, len(provider.Keys()), len(provider.KeyWeights))
+			}
+			for _, weight := range provider.KeyWeights {
+				if weight <= 0 {
+					return fmt.Errorf("provider %s: key_weights must be greater than 0", provider.Name)
+				}
+			}
+		}
+		switch provider.Type {
+		case "", models.ProviderTypeOpenAI:
+		case models.ProviderTypeAzure:
+			if provider.Deployment == "" {
+				return fmt.Errorf("provider %s: deployment is required for azure providers", provider.Name)
+			}
+			if provider.APIVersion == "" {
+				return fmt.Errorf("provider %s: api_version is required for azure providers", provider.Name)
+			}
+		default:
+			return fmt.Errorf("provider %s: unknown type %q", provider.Name, provider.Type)
+		}
+		if provider.Timeout != "" {
+			if timeout, err := time.ParseDuration(provider.Timeout); err != nil {
+				return fmt.Errorf("provider %s: invalid timeout format: %w", provider.Name, err)
+			} else if timeout <= 0 {
+				return fmt.Errorf("provider %s: timeout must be greater than 0", provider.Name)
+			}
+		}
+		if _, err := provider.ParsePromptTemplate(); err != nil {
+			return fmt.Errorf("provider %s: invalid prompt_template: %w", provider.Name, err)
+		}
+		if provider.Concurrency < 0 {
+			return fmt.Errorf("provider %s: concurrency cannot be negative", provider.Name)
+		}
+		for _, model := range provider.ReasoningModels {
+			if !slices.Contains(provider.Models, model) {
+				return fmt.Errorf("provider %s: reasoning model %s is not one of its models", provider.Name, model)
+			}
+		}
+		if provider.Tokenizer != "" && !slices.Contains(models.Tokenizers(), provider.Tokenizer) {
+			return fmt.Errorf("provider %s: unknown tokenizer %q: must be one of %s", provider.Name, provider.Tokenizer, strings.Join(models.Tokenizers(), ", "))
+		}
+		for model, tokenizer := range provider.Tokenizers {
+			if !slices.Contains(provider.Models, model) {
+				return fmt.Errorf("provider %s: tokenizer model %s is not one of its models", provider.Name, model)
+			}
+			if !slices.Contains(models.Tokenizers(), tokenizer) {
+				return fmt.Errorf("provider %s: unknown tokenizer %q for model %s: must be one of %s", provider.Name, tokenizer, model, strings.Join(models.Tokenizers(), ", "))
+			}
+		}
+		if provider.ProxyURL != "" {
+			if err := validateProxyURL(provider.ProxyURL); err != nil {
+				return fmt.Errorf("provider %s: invalid proxy_url: %w", provider.Name, err)
+			}
+		}
+		if sla := provider.SLA; sla != nil {
+			if sla.P50Latency < 0 || sla.P95Latency < 0 || sla.P99Latency < 0 || sla.MinThroughput < 0 {
+				return fmt.Errorf("provider %s: sla values cannot be negative", provider.Name)
+			}
+			if sla.MaxErrorRate != nil && (*sla.MaxErrorRate < 0 || *sla.MaxErrorRate > 100) {
+				return fmt.Errorf("provider %s: sla max_error_rate must be between 0 and 100", provider.Name)
+			}
 		}
 		if len(provider.Models) == 0 {
 			return fmt.Errorf("provider %s: at least one model is required", provider.Name)
@@ -114,14 +350,122 @@ func (m *Manager) validate() error {
 		return fmt.Errorf("requests must be greater than 0")
 	}
 
+	if m.config.Benchmark.ArrivalRate < 0 {
+		return fmt.Errorf("arrival_rate cannot be negative")
+	}
+
+	if m.config.Benchmark.FailFast < 0 {
+		return fmt.Errorf("fail_fast cannot be negative")
+	}
+	if m.config.Benchmark.AbortAfterTimeouts < 0 {
+		return fmt.Errorf("abort_after_timeouts cannot be negative")
+	}
+	if m.config.Benchmark.PrewarmConnections && m.config.Benchmark.DisableKeepAlives {
+		return fmt.Errorf("prewarm_connections cannot be combined with disable_keepalives: warmed connections would not be reused")
+	}
+	if m.config.Benchmark.MaxParallelProviders < 0 {
+		return fmt.Errorf("max_parallel_providers cannot be negative")
+	}
+
+	if addr := m.config.Benchmark.StatsDAddr; addr != "" {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return fmt.Errorf("invalid statsd_addr %q: %w", addr, err)
+		}
+	}
+
+	if scoring := m.config.Benchmark.Scoring; scoring != nil {
+		if err := ValidateScoreWeights(*scoring); err != nil {
+			return fmt.Errorf("scoring: %w", err)
+		}
+	}
+
+	if matrix := m.config.Benchmark.Matrix; matrix != nil {
+		if err := ValidateMatrix(*matrix); err != nil {
+			return fmt.Errorf("matrix: %w", err)
+		}
+	}
+
+	for _, color := range m.config.Charts.Colors {
+		if !colorPattern.MatchString(color) {
+			return fmt.Errorf("charts: invalid color %q: use #RRGGBB or an ANSI color number", color)
+		}
+	}
+
 	// Validate timeout format
 	if _, err := time.ParseDuration(m.config.Benchmark.Timeout); err != nil {
 		return fmt.Errorf("invalid timeout format: %w", err)
 	}
+	if _, err := time.ParseDuration(m.config.Benchmark.ConnectionTimeout); err != nil {
+		return fmt.Errorf("invalid connection_timeout format: %w", err)
+	}
+	if m.config.Benchmark.MaxDuration != "" {
+		if _, err := time.ParseDuration(m.config.Benchmark.MaxDuration); err != nil {
+			return fmt.Errorf("invalid max_duration format: %w", err)
+		}
+	}
+	if m.config.Benchmark.StreamStallTimeout != "" {
+		if _, err := time.ParseDuration(m.config.Benchmark.StreamStallTimeout); err != nil {
+			return fmt.Errorf("invalid stream_stall_timeout format: %w", err)
+		}
+	}
 
 	return nil
 }
 
+// ValidateScoreWeights checks that weights are non-negative and not all zero
+func ValidateScoreWeights(weights models.ScoreWeights) error {
+	if weights.Latency < 0 || weights.Throughput < 0 || weights.ErrorRate < 0 {
+		return fmt.Errorf("weights cannot be negative")
+	}
+	if weights.Latency+weights.Throughput+weights.ErrorRate == 0 {
+		return fmt.Errorf("at least one weight must be greater than 0")
+	}
+	switch weights.ErrorPenalty {
+	case "", models.ErrorPenaltyNone, models.ErrorPenaltyLinear, models.ErrorPenaltySteep:
+	default:
+		return fmt.Errorf("invalid error_penalty %q: must be none, linear or steep", weights.ErrorPenalty)
+	}
+	return nil
+}
+
+// ValidateMatrix checks that every matrix value is greater than 0
+func ValidateMatrix(matrix models.MatrixConfig) error {
+	for _, concurrency := range matrix.Concurrency {
+		if concurrency <= 0 {
+			return fmt.Errorf("concurrency values must be greater than 0")
+		}
+	}
+	for _, maxTokens := range matrix.MaxTokens {
+		if maxTokens <= 0 {
+			return fmt.Errorf("max_tokens values must be greater than 0")
+		}
+	}
+	return nil
+}
+
+// warn prints a warning for every risky setting of a valid configuration,
+// once when it is loaded rather than every time it is used
+func (m *Manager) warn() {
+	for _, provider := range m.config.Benchmark.Providers {
+		if provider.InsecureSkipVerify {
+			fmt.Fprintf(os.Stderr, "⚠️  WARNING: TLS certificate verification is disabled for provider %s (insecure_skip_verify)\n", provider.Name)
+		}
+	}
+}
+
+// validateProxyURL checks that a proxy URL is absolute, with a scheme and
+// a host
+func validateProxyURL(value string) error {
+	proxyURL, err := url.ParseRequestURI(value)
+	if err != nil {
+		return err
+	}
+	if proxyURL.Scheme == "" || proxyURL.Host == "" {
+		return fmt.Errorf("%q must include a scheme and host, e.g. http://proxy:8080", value)
+	}
+	return nil
+}
+
 // GetConfig returns the loaded configuration
 func (m *Manager) GetConfig() *Config {
 	return m.config
@@ -163,14 +507,29 @@ func (m *Manager) CreateSampleConfig(path string) error {
         - claude-3-sonnet-20240229
         - claude-3-opus-20240229
     - name: azure-openai
+      type: azure
       base_url: https://your-resource.openai.azure.com/
       api_key: your-azure-api-key
+      deployment: your-deployment-name
+      api_version: 2024-06-01
       models:
-        - gpt-35-turbo
         - gpt-4
   concurrency: 2
   requests: 50
   timeout: 30s
+  connection_timeout: 60s
+
+# Profiles override the settings above when selected with --profile
+# profiles:
+#   staging:
+#     benchmark:
+#       providers:
+#         - name: staging
+#           base_url: https://staging.example.com/v1
+#           api_key: your-staging-api-key
+#           models:
+#             - gpt-4
+#       requests: 10
 `
 
 	// Write the YAML content directly to file
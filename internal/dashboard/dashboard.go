@@ -0,0 +1,136 @@
+package dashboard
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"llmbench/internal/httpjson"
+	"llmbench/internal/models"
+	"llmbench/internal/storage"
+)
+
+// Run describes a saved results file in the run list
+type Run struct {
+	File        string    `json:"file"` // name within the results directory
+	Timestamp   time.Time `json:"timestamp"`
+	Message     string    `json:"message"`
+	Requests    int       `json:"requests"`
+	Concurrency int       `json:"concurrency"`
+	Streaming   bool      `json:"streaming"`
+	RunID       string    `json:"run_id,omitempty"`
+}
+
+// RunDetail is a saved run with its summaries, without per-request results
+type RunDetail struct {
+	Run
+	Summaries map[string]models.BenchmarkSummary `json:"summaries"`
+}
+
+// Dashboard serves a browsable page of the results saved in a directory.
+// Files are read on every request, so runs saved while it is up show up
+// on the page's next refresh
+type Dashboard struct {
+	dir string
+}
+
+// New creates a dashboard of the results saved in dir
+func New(dir string) *Dashboard {
+	return &Dashboard{dir: dir}
+}
+
+// Handler returns the HTTP handler serving the page and its JSON API
+func (d *Dashboard) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", d.handlePage)
+	mux.HandleFunc("/api/runs", d.handleRuns)
+	mux.HandleFunc("/api/runs/", d.handleRun)
+	return mux
+}
+
+// handlePage serves the dashboard page
+func (d *Dashboard) handlePage(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		httpjson.Error(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, page)
+}
+
+// handleRuns lists the saved runs, newest first
+func (d *Dashboard) handleRuns(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		httpjson.Error(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	saved, err := storage.ListResults(d.dir)
+	if err != nil {
+		httpjson.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	runs := make([]Run, 0, len(saved))
+	for _, entry := range saved {
+		runs = append(runs, runFor(entry.Path, entry.Timestamp, entry.Metadata))
+	}
+	httpjson.Write(w, http.StatusOK, runs)
+}
+
+// handleRun replies with the summaries of one saved run
+func (d *Dashboard) handleRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		httpjson.Error(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	// Only files listed in the directory are served, whatever the path holds
+	name := strings.TrimPrefix(r.URL.Path, "/api/runs/")
+	saved, err := storage.ListResults(d.dir)
+	if err != nil {
+		httpjson.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	for _, entry := range saved {
+		if filepath.Base(entry.Path) != name {
+			continue
+		}
+
+		resultsFile, err := storage.LoadResults(entry.Path)
+		if err != nil {
+			httpjson.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+		httpjson.Write(w, http.StatusOK, RunDetail{
+			Run:       runFor(entry.Path, resultsFile.Timestamp, resultsFile.Metadata),
+			Summaries: resultsFile.Summaries,
+		})
+		return
+	}
+
+	httpjson.Error(w, http.StatusNotFound, fmt.Errorf("no saved results named %q", name))
+}
+
+// runFor describes a saved results file
+func runFor(path string, timestamp time.Time, metadata models.BenchmarkMetadata) Run {
+	return Run{
+		File:        filepath.Base(path),
+		Timestamp:   timestamp,
+		Message:     metadata.Message,
+		Requests:    metadata.Requests,
+		Concurrency: metadata.Concurrency,
+		Streaming:   metadata.Streaming,
+		RunID:       metadata.RunID,
+	}
+}
@@ -0,0 +1,164 @@
+package dashboard
+
+// page is the single-page dashboard: it lists the saved runs, shows the
+// summaries of the selected one as a sortable table with latency and
+// throughput bars, and polls the API so new runs appear on their own
+const page = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>llmbench dashboard</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem; color: #222; }
+h1 { font-size: 1.4rem; }
+h2 { font-size: 1.1rem; margin-top: 2rem; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 1rem; }
+th, td { padding: 0.35rem 0.6rem; border-bottom: 1px solid #ddd; text-align: left; white-space: nowrap; }
+th { cursor: pointer; user-select: none; background: #f5f5f5; }
+th.asc::after { content: " ▲"; }
+th.desc::after { content: " ▼"; }
+td.num { text-align: right; font-variant-numeric: tabular-nums; }
+#runs tbody tr { cursor: pointer; }
+#runs tbody tr:hover { background: #f0f6ff; }
+#runs tbody tr.selected { background: #dbe9ff; }
+.chart { display: grid; grid-template-columns: max-content 1fr max-content; gap: 0.3rem 0.6rem; align-items: center; margin-bottom: 1.5rem; }
+.bar { height: 0.9rem; background: #4f8ff7; border-radius: 2px; }
+.bar.throughput { background: #3fb37f; }
+.muted { color: #888; }
+</style>
+</head>
+<body>
+<h1>llmbench dashboard</h1>
+<p class="muted" id="status">Loading…</p>
+<table id="runs"></table>
+<div id="detail"></div>
+<script>
+const runColumns = [
+  {key: "timestamp", label: "Date", format: v => new Date(v).toLocaleString()},
+  {key: "file", label: "File"},
+  {key: "message", label: "Message"},
+  {key: "requests", label: "Requests", num: true},
+  {key: "concurrency", label: "Concurrency", num: true},
+  {key: "streaming", label: "Streaming", format: v => v ? "yes" : "no"},
+];
+const summaryColumns = [
+  {key: "key", label: "Provider/Model"},
+  {key: "total_requests", label: "Requests", num: true},
+  {key: "error_rate", label: "Error Rate", num: true, format: v => (v || 0).toFixed(2) + "%"},
+  {key: "avg_response_time", label: "Avg", num: true, format: ms},
+  {key: "p50_response_time", label: "P50", num: true, format: ms},
+  {key: "p95_response_time", label: "P95", num: true, format: ms},
+  {key: "p99_response_time", label: "P99", num: true, format: ms},
+  {key: "avg_time_to_first_token", label: "Avg TTFT", num: true, format: ms},
+  {key: "avg_token_throughput", label: "Tokens/sec", num: true, format: v => v ? v.toFixed(2) : "-"},
+];
+
+let runs = [];
+let selected = null;
+let detail = null;
+const sorts = {runs: {key: "timestamp", desc: true}, summaries: {key: "key", desc: false}};
+
+// Durations are serialized in nanoseconds
+function ms(v) {
+  return v ? (v / 1e6).toFixed(1) + "ms" : "-";
+}
+
+function escape(s) {
+  return String(s).replace(/[&<>"]/g, c => ({"&": "&amp;", "<": "&lt;", ">": "&gt;", '"': "&quot;"}[c]));
+}
+
+function sorted(rows, sort) {
+  return rows.slice().sort((a, b) => {
+    const x = a[sort.key], y = b[sort.key];
+    const order = x < y ? -1 : x > y ? 1 : 0;
+    return sort.desc ? -order : order;
+  });
+}
+
+function renderTable(table, name, columns, rows, rowAttrs) {
+  const sort = sorts[name];
+  const head = columns.map(c => {
+    const cls = c.key === sort.key ? (sort.desc ? "desc" : "asc") : "";
+    return '<th class="' + cls + '" data-key="' + c.key + '">' + c.label + "</th>";
+  }).join("");
+  const body = sorted(rows, sort).map(row => {
+    const cells = columns.map(c => {
+      const value = c.format ? c.format(row[c.key]) : (row[c.key] ?? "");
+      return '<td class="' + (c.num ? "num" : "") + '">' + escape(value) + "</td>";
+    }).join("");
+    return "<tr " + (rowAttrs ? rowAttrs(row) : "") + ">" + cells + "</tr>";
+  }).join("");
+  table.innerHTML = "<thead><tr>" + head + "</tr></thead><tbody>" + body + "</tbody>";
+  table.querySelectorAll("th").forEach(th => th.onclick = () => {
+    sort.desc = sort.key === th.dataset.key ? !sort.desc : false;
+    sort.key = th.dataset.key;
+    render();
+  });
+}
+
+function renderChart(title, rows, key, format, cls) {
+  const values = rows.filter(r => r[key] > 0);
+  if (values.length === 0) {
+    return "";
+  }
+  const max = Math.max(...values.map(r => r[key]));
+  const bars = values.map(r =>
+    "<span>" + escape(r.key) + "</span>" +
+    '<div class="bar ' + cls + '" style="width:' + (r[key] / max * 100).toFixed(1) + '%"></div>' +
+    '<span class="muted">' + format(r[key]) + "</span>"
+  ).join("");
+  return "<h2>" + title + '</h2><div class="chart">' + bars + "</div>";
+}
+
+function render() {
+  const runsTable = document.getElementById("runs");
+  renderTable(runsTable, "runs", runColumns, runs,
+    run => 'data-file="' + escape(run.file) + '"' + (run.file === selected ? ' class="selected"' : ""));
+  runsTable.querySelectorAll("tbody tr").forEach(tr => tr.onclick = () => select(tr.dataset.file));
+
+  const container = document.getElementById("detail");
+  if (!detail) {
+    container.innerHTML = "";
+    return;
+  }
+  const rows = Object.entries(detail.summaries || {}).map(([key, s]) => Object.assign({key}, s));
+  container.innerHTML = "<h2>" + escape(detail.file) + '</h2><table id="summaries"></table>' +
+    renderChart("Average latency", rows, "avg_response_time", ms, "") +
+    renderChart("Token throughput", rows, "avg_token_throughput", v => v.toFixed(2) + " tokens/sec", "throughput");
+  renderTable(document.getElementById("summaries"), "summaries", summaryColumns, rows);
+}
+
+async function select(file) {
+  selected = file;
+  const response = await fetch("/api/runs/" + encodeURIComponent(file));
+  detail = response.ok ? await response.json() : null;
+  render();
+}
+
+async function refresh() {
+  try {
+    const response = await fetch("/api/runs");
+    const body = await response.json();
+    if (!response.ok) {
+      throw new Error(body.error);
+    }
+    const changed = JSON.stringify(body) !== JSON.stringify(runs);
+    runs = body;
+    document.getElementById("status").textContent =
+      runs.length + " saved run" + (runs.length === 1 ? "" : "s") + ", updated " + new Date().toLocaleTimeString();
+    if (!selected && runs.length > 0) {
+      await select(runs[0].file);
+    } else if (changed) {
+      render();
+    }
+  } catch (err) {
+    document.getElementById("status").textContent = "Failed to load runs: " + err.message;
+  }
+}
+
+refresh();
+setInterval(refresh, 5000);
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,24 @@
+package httpjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// Write writes a JSON response with the given status code. The status is
+// sent by then, so a body that fails to encode, or a client gone away, can
+// only be logged
+func Write(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  WARNING: failed to write response: %v\n", err)
+	}
+}
+
+// Error writes a JSON error response with the given status code
+func Error(w http.ResponseWriter, status int, err error) {
+	Write(w, status, map[string]string{"error": err.Error()})
+}
@@ -1,13 +1,205 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Provider types
+const (
+	ProviderTypeOpenAI = "openai"
+	ProviderTypeAzure  = "azure"
+)
 
 // Provider represents an LLM service provider configuration
 type Provider struct {
 	Name    string   `mapstructure:"name" yaml:"name"`
+	Type    string   `mapstructure:"type" yaml:"type,omitempty"`
 	BaseURL string   `mapstructure:"base_url" yaml:"base_url"`
 	APIKey  string   `mapstructure:"api_key" yaml:"api_key"`
 	Models  []string `mapstructure:"models" yaml:"models"`
+
+	// APIKeys are more keys, rotated round-robin with api_key across
+	// requests to spread load over per-key rate limits
+	APIKeys []string `mapstructure:"api_keys" yaml:"api_keys,omitempty"`
+
+	// KeyWeights, when set, weighs the rotation with one weight per key,
+	// api_key first: a key weighing twice as much gets twice the requests
+	KeyWeights []int `mapstructure:"key_weights" yaml:"key_weights,omitempty"`
+
+	// TokenAuth, when set, authenticates with short-lived bearer tokens
+	// instead of the static api_key
+	TokenAuth *TokenAuth `mapstructure:"token_auth" yaml:"token_auth,omitempty"`
+
+	// Tags group providers so that runs can select them with --tag
+	Tags []string `mapstructure:"tags" yaml:"tags,omitempty"`
+
+	// Timeout, when set, overrides the benchmark's request timeout for
+	// this provider only
+	Timeout string `mapstructure:"timeout" yaml:"timeout,omitempty"`
+
+	// Concurrency, when set, overrides the benchmark's concurrency for
+	// this provider only
+	Concurrency int `mapstructure:"concurrency" yaml:"concurrency,omitempty"`
+
+	// PromptTemplate, when set, wraps every prompt sent to this provider,
+	// e.g. "Answer tersely.\n\n{{.Message}}"; see PromptData for the fields
+	PromptTemplate string `mapstructure:"prompt_template" yaml:"prompt_template,omitempty"`
+
+	// ReasoningModels lists the models that take max_completion_tokens
+	// instead of max_tokens and report reasoning tokens separately
+	ReasoningModels []string `mapstructure:"reasoning_models" yaml:"reasoning_models,omitempty"`
+
+	// Tokenizer is the encoding tokens of this provider's models are counted
+	// with, e.g. o200k_base; Tokenizers overrides it per model. Without one,
+	// the provider's reported usage is preferred and local counts are estimates
+	Tokenizer  string            `mapstructure:"tokenizer" yaml:"tokenizer,omitempty"`
+	Tokenizers map[string]string `mapstructure:"tokenizers" yaml:"tokenizers,omitempty"`
+
+	// Azure OpenAI settings
+	Deployment string `mapstructure:"deployment" yaml:"deployment,omitempty"`
+	APIVersion string `mapstructure:"api_version" yaml:"api_version,omitempty"`
+
+	// Network settings
+	ProxyURL           string            `mapstructure:"proxy_url" yaml:"proxy_url,omitempty"`
+	InsecureSkipVerify bool              `mapstructure:"insecure_skip_verify" yaml:"insecure_skip_verify,omitempty"`
+	Headers            map[string]string `mapstructure:"headers" yaml:"headers,omitempty"`
+
+	// DisableKeepAlives opens a new connection for every request; it is set
+	// from the benchmark's disable_keepalives rather than per provider
+	DisableKeepAlives bool `mapstructure:"-" yaml:"-"`
+
+	// SLA, when set, is checked against every model's summary after a run
+	SLA *SLA `mapstructure:"sla" yaml:"sla,omitempty"`
+}
+
+// SLA sets the service levels a provider is expected to meet; zero values
+// are not checked
+type SLA struct {
+	P50Latency    time.Duration `mapstructure:"p50_latency" yaml:"p50_latency,omitempty"`
+	P95Latency    time.Duration `mapstructure:"p95_latency" yaml:"p95_latency,omitempty"`
+	P99Latency    time.Duration `mapstructure:"p99_latency" yaml:"p99_latency,omitempty"`
+	MaxErrorRate  *float64      `mapstructure:"max_error_rate" yaml:"max_error_rate,omitempty"` // percent
+	MinThroughput float64       `mapstructure:"min_throughput" yaml:"min_throughput,omitempty"` // tokens/sec, streaming only
+}
+
+// TokenAuth fetches the bearer token of a provider before the run, and again
+// whenever it expires, either by running a command or from an OAuth2 token
+// endpoint with the client credentials grant
+type TokenAuth struct {
+	Command string `mapstructure:"command" yaml:"command,omitempty"` // run with sh -c, its trimmed output is the token
+
+	URL          string `mapstructure:"url" yaml:"url,omitempty"`
+	ClientID     string `mapstructure:"client_id" yaml:"client_id,omitempty"`
+	ClientSecret string `mapstructure:"client_secret" yaml:"client_secret,omitempty"`
+	Scope        string `mapstructure:"scope" yaml:"scope,omitempty"`
+
+	// TTL is how long a token is used for when the endpoint doesn't say
+	// with expires_in, as for commands; 5 minutes when unset
+	TTL time.Duration `mapstructure:"ttl" yaml:"ttl,omitempty"`
+}
+
+// IsAzure reports whether the provider is an Azure OpenAI deployment
+func (p Provider) IsAzure() bool {
+	return p.Type == ProviderTypeAzure
+}
+
+// Keys returns every API key of the provider, api_key first
+func (p Provider) Keys() []string {
+	if p.APIKey == "" {
+		return p.APIKeys
+	}
+	return append([]string{p.APIKey}, p.APIKeys...)
+}
+
+// WithKey returns the provider authenticating with its index-th key only
+func (p Provider) WithKey(index int) Provider {
+	p.APIKey = p.Keys()[index]
+	p.APIKeys = nil
+	return p
+}
+
+// PromptData is what a provider's prompt template is rendered with
+type PromptData struct {
+	Message  string // the prompt being sent: --message, or the current prompt, test case, trace entry or turn
+	Provider string
+	Model    string
+}
+
+// ParsePromptTemplate parses the provider's prompt template, checking that
+// it renders, or returns nil when the provider has none
+func (p Provider) ParsePromptTemplate() (*template.Template, error) {
+	if p.PromptTemplate == "" {
+		return nil, nil
+	}
+
+	tmpl, err := template.New(p.Name).Parse(p.PromptTemplate)
+	if err != nil {
+		return nil, err
+	}
+	// Unknown fields only fail when rendering
+	if err := tmpl.Execute(io.Discard, PromptData{}); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// tokenizerModels maps every supported encoding to a model using it: the
+// token counter picks its encoding from the model name
+var tokenizerModels = map[string]string{
+	"o200k_base":  "gpt-4o",
+	"cl100k_base": "gpt-4",
+	"p50k_base":   "text-davinci-003",
+	"r50k_base":   "davinci",
+}
+
+// Tokenizers lists the supported tokenizer encodings
+func Tokenizers() []string {
+	return slices.Sorted(maps.Keys(tokenizerModels))
+}
+
+// TokenizerFor returns the encoding configured for the model, or "" when
+// its tokenizer is unknown
+func (p Provider) TokenizerFor(model string) string {
+	if tokenizer, ok := p.Tokenizers[model]; ok {
+		return tokenizer
+	}
+	return p.Tokenizer
+}
+
+// TokenizerModel returns the model name to count the model's tokens as, so
+// that the counter uses its configured encoding, and whether it has one
+func (p Provider) TokenizerModel(model string) (string, bool) {
+	countAs, ok := tokenizerModels[p.TokenizerFor(model)]
+	if !ok {
+		return model, false
+	}
+	return countAs, true
+}
+
+// IsReasoningModel reports whether the provider flags the model as a
+// reasoning model
+func (p Provider) IsReasoningModel(model string) bool {
+	return slices.Contains(p.ReasoningModels, model)
+}
+
+// HasAnyTag reports whether the provider has at least one of the tags
+func (p Provider) HasAnyTag(tags []string) bool {
+	for _, tag := range tags {
+		for _, providerTag := range p.Tags {
+			if strings.EqualFold(tag, providerTag) {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // BenchmarkConfig represents the benchmark configuration
@@ -16,6 +208,110 @@ type BenchmarkConfig struct {
 	Concurrency int        `mapstructure:"concurrency" yaml:"concurrency"`
 	Requests    int        `mapstructure:"requests" yaml:"requests"`
 	Timeout     string     `mapstructure:"timeout" yaml:"timeout"`
+
+	// ConnectionTimeout bounds each connection test attempt; it is usually
+	// longer than Timeout to allow for cold starts
+	ConnectionTimeout string `mapstructure:"connection_timeout" yaml:"connection_timeout,omitempty"`
+
+	// MaxDuration, when set, stops starting new requests to a provider once
+	// it has been benchmarked that long
+	MaxDuration string `mapstructure:"max_duration" yaml:"max_duration,omitempty"`
+
+	// StreamStallTimeout, when set, fails a streaming request once no chunk
+	// has arrived for that long, instead of waiting for Timeout
+	StreamStallTimeout string `mapstructure:"stream_stall_timeout" yaml:"stream_stall_timeout,omitempty"`
+
+	// FailFast, when set, stops starting requests to a provider/model after
+	// that many consecutive failures
+	FailFast int `mapstructure:"fail_fast" yaml:"fail_fast,omitempty"`
+
+	// AbortAfterTimeouts, when set, aborts the whole run after that many
+	// consecutive timeouts across all providers, as the network is likely down
+	AbortAfterTimeouts int `mapstructure:"abort_after_timeouts" yaml:"abort_after_timeouts,omitempty"`
+
+	// DisableKeepAlives, when set, opens a new connection for every request
+	// so that connection setup is part of every measurement
+	DisableKeepAlives bool `mapstructure:"disable_keepalives" yaml:"disable_keepalives,omitempty"`
+
+	// PrewarmConnections, when set, resolves every provider's host and
+	// opens a connection to it before the measured run, so the first
+	// request doesn't pay for DNS and TLS
+	PrewarmConnections bool `mapstructure:"prewarm_connections" yaml:"prewarm_connections,omitempty"`
+
+	// StrictResponses, when set, fails responses that succeeded at the HTTP
+	// layer but hold no content or an error field in their body
+	StrictResponses bool `mapstructure:"strict_responses" yaml:"strict_responses,omitempty"`
+
+	// MaxParallelProviders, when set, caps how many providers are
+	// benchmarked at once; the others wait for one to finish
+	MaxParallelProviders int `mapstructure:"max_parallel_providers" yaml:"max_parallel_providers,omitempty"`
+
+	// Scoring, when set, ranks providers with a weighted 0-100 score
+	Scoring *ScoreWeights `mapstructure:"scoring" yaml:"scoring,omitempty"`
+
+	// Telemetry, when set, exports a trace span for every request
+	Telemetry *TelemetryConfig `mapstructure:"telemetry" yaml:"telemetry,omitempty"`
+
+	// StatsDAddr, when set, is the host:port of a StatsD agent receiving
+	// timing and counter metrics for every request as the run goes
+	StatsDAddr string `mapstructure:"statsd_addr" yaml:"statsd_addr,omitempty"`
+
+	// ArrivalRate, when set, starts requests to each provider/model as a
+	// Poisson process with this mean rate per second, however many are in
+	// flight, instead of keeping Concurrency requests in flight
+	ArrivalRate float64 `mapstructure:"arrival_rate" yaml:"arrival_rate,omitempty"`
+
+	// Matrix, when set, defines the grid swept by a matrix run
+	Matrix *MatrixConfig `mapstructure:"matrix" yaml:"matrix,omitempty"`
+
+	// UserAgent, when set, replaces the client's User-Agent header
+	UserAgent string `mapstructure:"user_agent" yaml:"user_agent,omitempty"`
+
+	// RunID, when set, tags every request with an X-Run-ID header and is
+	// recorded in saved results, to correlate them with server-side logs
+	RunID string `mapstructure:"run_id" yaml:"run_id,omitempty"`
+}
+
+// MatrixConfig lists the values swept by a matrix run, which benchmarks
+// every combination; an empty list keeps the regular setting
+type MatrixConfig struct {
+	Concurrency []int `mapstructure:"concurrency" yaml:"concurrency,omitempty"`
+	MaxTokens   []int `mapstructure:"max_tokens" yaml:"max_tokens,omitempty"`
+}
+
+// RunIDHeader is the header carrying the run id of a benchmark
+const RunIDHeader = "X-Run-ID"
+
+// TelemetryConfig configures OpenTelemetry trace export
+type TelemetryConfig struct {
+	OTLPEndpoint string            `mapstructure:"otlp_endpoint" yaml:"otlp_endpoint"` // e.g. http://localhost:4318
+	Headers      map[string]string `mapstructure:"headers" yaml:"headers,omitempty"`
+	ServiceName  string            `mapstructure:"service_name" yaml:"service_name,omitempty"`
+}
+
+// Error penalty curves, applied to the whole score on top of the error
+// rate weight
+const (
+	ErrorPenaltyNone   = "none"   // the error rate only counts through its weight
+	ErrorPenaltyLinear = "linear" // score × success rate
+	ErrorPenaltySteep  = "steep"  // score × success rate³
+)
+
+// ScoreWeights sets how much each metric contributes to a provider's score
+type ScoreWeights struct {
+	Latency    float64 `mapstructure:"latency" yaml:"latency" json:"latency"`
+	Throughput float64 `mapstructure:"throughput" yaml:"throughput" json:"throughput"`
+	ErrorRate  float64 `mapstructure:"error_rate" yaml:"error_rate" json:"error_rate"`
+
+	// ErrorPenalty scales the score down by the success rate, so that a fast
+	// but failing provider can't top the ranking: none, linear (the
+	// default) or steep
+	ErrorPenalty string `mapstructure:"error_penalty" yaml:"error_penalty,omitempty" json:"error_penalty,omitempty"`
+}
+
+// DefaultScoreWeights weighs every metric equally
+func DefaultScoreWeights() ScoreWeights {
+	return ScoreWeights{Latency: 1, Throughput: 1, ErrorRate: 1}
 }
 
 // BenchmarkRequest represents a single benchmark request
@@ -24,14 +320,95 @@ type BenchmarkRequest struct {
 	Model     string        `json:"model"`
 	MaxTokens int           `json:"max_tokens,omitempty"`
 	Stream    bool          `json:"stream,omitempty"`
+
+	// Prompts, when set, are cycled through as the user message across
+	// requests instead of sending the same Messages every time
+	Prompts []string `json:"prompts,omitempty"`
+
+	// Structured output settings
+	ResponseFormat string         `json:"response_format,omitempty"`
+	JSONSchema     map[string]any `json:"json_schema,omitempty"`
+
+	// Conversation, when set, is replayed turn by turn as user messages
+	// following Messages, with each assistant reply fed back in
+	Conversation []string `json:"conversation,omitempty"`
+
+	// TestCases, when set, are round-robined like Prompts and each response
+	// is graded against the test case's expectations
+	TestCases []TestCase `json:"test_cases,omitempty"`
+
+	// Trace, when set, is replayed instead of sending Requests requests:
+	// each entry's prompt is sent at its offset from the start of the run
+	Trace []TraceEntry `json:"trace,omitempty"`
+
+	// Images, as URLs or base64 data URLs, are sent as image parts alongside
+	// the text of the first user message of every request
+	Images []string `json:"images,omitempty"`
+
+	// Sampling settings, left to the provider's defaults when nil
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	Seed        *int64   `json:"seed,omitempty"`
+
+	// RandomizePrompt appends a random nonce to every request's prompt so
+	// that no two requests are identical, defeating response caches
+	RandomizePrompt bool `json:"randomize_prompt,omitempty"`
+
+	// RawBody, when set, is sent verbatim instead of a body built from the
+	// settings here, with only its model set to each provider/model's
+	RawBody json.RawMessage `json:"raw_body,omitempty"`
+
+	// Generation settings, also left to the provider's defaults when unset
+	Stop             []string `json:"stop,omitempty"`
+	FrequencyPenalty *float64 `json:"frequency_penalty,omitempty"`
+	PresencePenalty  *float64 `json:"presence_penalty,omitempty"`
+
+	// N asks for that many choices per request, when above 1
+	N int `json:"n,omitempty"`
+
+	// Logprobs asks for the log probabilities of the output tokens, and
+	// TopLogprobs for that many most likely alternatives at each position
+	Logprobs    bool   `json:"logprobs,omitempty"`
+	TopLogprobs *int64 `json:"top_logprobs,omitempty"`
+}
+
+// TraceEntry is a recorded request to replay
+type TraceEntry struct {
+	OffsetMs int64  `json:"offset_ms" yaml:"offset_ms"` // from the start of the trace
+	Prompt   string `json:"prompt" yaml:"prompt"`
+}
+
+// Offset returns when the entry is due, from the start of the trace
+func (e TraceEntry) Offset() time.Duration {
+	return time.Duration(e.OffsetMs) * time.Millisecond
 }
 
+// Response formats
+const (
+	ResponseFormatText       = "text"
+	ResponseFormatJSONObject = "json_object"
+	ResponseFormatJSONSchema = "json_schema"
+)
+
 // ChatMessage represents a chat message
 type ChatMessage struct {
 	Role    string `json:"role"`
 	Content string `json:"content"`
 }
 
+// Error kinds recorded on failed results
+const (
+	ErrorKindTimeout         = "timeout"
+	ErrorKindAuth            = "auth"
+	ErrorKindRateLimit       = "rate_limit"
+	ErrorKindServer          = "server"
+	ErrorKindNetwork         = "network"
+	ErrorKindStreamStall     = "stream_stall"     // stream opened, then no chunk within the stall timeout
+	ErrorKindInvalidResponse = "invalid_response" // 2xx response without content or with an error body, with strict responses
+	ErrorKindSkipped         = "skipped"          // conversation turn not sent after an earlier turn failed
+	ErrorKindOther           = "other"
+)
+
 // BenchmarkResult represents the result of a benchmark test
 type BenchmarkResult struct {
 	Provider     string        `json:"provider"`
@@ -39,8 +416,53 @@ type BenchmarkResult struct {
 	Success      bool          `json:"success"`
 	ResponseTime time.Duration `json:"response_time"`
 	TokensUsed   int           `json:"tokens_used,omitempty"`
+	InputTokens  int           `json:"input_tokens,omitempty"`
+	OutputTokens int           `json:"output_tokens,omitempty"`
 	Error        string        `json:"error,omitempty"`
+	ErrorKind    string        `json:"error_kind,omitempty"` // one of the ErrorKind constants
 	Response     string        `json:"response,omitempty"`
+	PromptIndex  int           `json:"prompt_index"`
+	Turn         int           `json:"turn,omitempty"`         // conversation turn depth, starting at 1
+	TraceOffset  time.Duration `json:"trace_offset,omitempty"` // offset of the replayed trace entry, whose index is PromptIndex
+	Index        int           `json:"index"`
+	StartedAt    time.Time     `json:"started_at"`
+	IsOutlier    bool          `json:"is_outlier,omitempty"` // response time over 3 scaled median absolute deviations from the provider/model median
+	KeyIndex     int           `json:"key_index,omitempty"`  // API key the request used, for providers with several keys
+
+	// Response body size, summed over chunks when streaming
+	ResponseBytes  int     `json:"response_bytes,omitempty"`
+	ByteThroughput float64 `json:"byte_throughput,omitempty"` // response bytes per second
+
+	// Input tokens served from the provider's prompt cache, when its usage
+	// reports them
+	CachedInputTokens int `json:"cached_input_tokens,omitempty"`
+
+	// Output tokens spent reasoning, for reasoning models
+	ReasoningTokens int `json:"reasoning_tokens,omitempty"`
+
+	// Why generation stopped, as reported by the provider: stop, length...
+	FinishReason string `json:"finish_reason,omitempty"`
+
+	// The response carried log probabilities
+	Logprobs bool `json:"logprobs,omitempty"`
+
+	// Choices returned when several were asked for; the first is the
+	// response, but output tokens are counted across all of them
+	Choices int `json:"choices,omitempty"`
+
+	// Token counts come from the local counter without a known tokenizer
+	// for the model, nor usage reported by the provider
+	TokensEstimated bool `json:"tokens_estimated,omitempty"`
+
+	// Whitelisted provider response headers (rate limits, request id)
+	ResponseHeaders map[string]string `json:"response_headers,omitempty"`
+
+	// Network phases of the request's last attempt; DNS, connect and TLS
+	// are zero when a pooled connection was reused
+	DNSTime     time.Duration `json:"dns_time,omitempty"`
+	ConnectTime time.Duration `json:"connect_time,omitempty"`
+	TLSTime     time.Duration `json:"tls_time,omitempty"`
+	TTFB        time.Duration `json:"ttfb,omitempty"` // from the request start to the first response byte
 	
 	// Streaming metrics
 	IsStreaming       bool          `json:"is_streaming"`
@@ -48,27 +470,210 @@ type BenchmarkResult struct {
 	TokenThroughput   float64       `json:"token_throughput,omitempty"` // tokens per second
 	StreamingTokens   int           `json:"streaming_tokens,omitempty"`
 	StreamingDuration time.Duration `json:"streaming_duration,omitempty"`
+	EmptyResponse     bool          `json:"empty_response,omitempty"` // stream succeeded but produced no content
+
+	// Correctness grading, for runs with test cases
+	Graded  bool `json:"graded,omitempty"`
+	Correct bool `json:"correct,omitempty"`
+}
+
+// FinishReasonLength is the finish reason of a response that hit max_tokens
+const FinishReasonLength = "length"
+
+// CompletedAt returns the time at which the request finished
+func (r BenchmarkResult) CompletedAt() time.Time {
+	return r.StartedAt.Add(r.ResponseTime)
 }
 
 // BenchmarkSummary represents the summary of all benchmark results
 type BenchmarkSummary struct {
-	Provider        string        `json:"provider"`
-	ModelName       string        `json:"model_name"`
-	TotalRequests   int           `json:"total_requests"`
-	SuccessfulReqs  int           `json:"successful_requests"`
-	FailedRequests  int           `json:"failed_requests"`
-	AvgResponseTime time.Duration `json:"avg_response_time"`
-	MinResponseTime time.Duration `json:"min_response_time"`
-	MaxResponseTime time.Duration `json:"max_response_time"`
-	TotalTokens     int           `json:"total_tokens"`
-	ErrorRate       float64       `json:"error_rate"`
+	Provider        string         `json:"provider"`
+	ModelName       string         `json:"model_name"`
+	TotalRequests   int            `json:"total_requests"`
+	SuccessfulReqs  int            `json:"successful_requests"`
+	FailedRequests  int            `json:"failed_requests"`
+	AvgResponseTime time.Duration  `json:"avg_response_time"`
+	MinResponseTime time.Duration  `json:"min_response_time"`
+	MaxResponseTime time.Duration  `json:"max_response_time"`
+	P50ResponseTime time.Duration  `json:"p50_response_time"`
+	P95ResponseTime time.Duration  `json:"p95_response_time"`
+	P99ResponseTime time.Duration  `json:"p99_response_time"`
+	TurnStats       []TurnStats    `json:"turn_stats,omitempty"`
+	KeyStats        []KeyStats     `json:"key_stats,omitempty"` // per API key, for providers with several keys
+	TotalTokens     int            `json:"total_tokens"`
+	AvgOutputTokens float64        `json:"avg_output_tokens,omitempty"`
+	MinOutputTokens int            `json:"min_output_tokens,omitempty"`
+	MaxOutputTokens int            `json:"max_output_tokens,omitempty"`
+	ErrorRate       float64        `json:"error_rate"`
+	ErrorKinds      map[string]int `json:"error_kinds,omitempty"`       // failed requests per error kind
+	Outliers        int            `json:"outliers,omitempty"`          // requests flagged IsOutlier
+	PlannedRequests int            `json:"planned_requests,omitempty"`  // requests the run meant to send; TotalRequests counts those that ran
+	SkippedRequests int            `json:"skipped_requests,omitempty"`  // planned requests that never ran, for any reason
+	NotStarted      int            `json:"not_started,omitempty"`       // requests skipped when the run was cut short by max duration
+	FailFastSkipped int            `json:"fail_fast_skipped,omitempty"` // requests skipped after too many consecutive failures
+	GradedRequests  int            `json:"graded_requests,omitempty"`
+	Accuracy        float64        `json:"accuracy,omitempty"` // percent of graded responses that were correct
+	Score           float64        `json:"score,omitempty"`    // weighted 0-100 score, relative to the best performer
+
+	// Average response time of the successful requests alone, which fast
+	// failures can't pull down
+	AvgSuccessResponseTime time.Duration `json:"avg_success_response_time,omitempty"`
+
+	// Latency of the first request to complete successfully, which may pay
+	// for a cold start the steady-state average hides
+	ColdStartLatency time.Duration `json:"cold_start_latency,omitempty"`
+
+	// Gaps between request starts, for runs with an arrival rate
+	InterArrival *InterArrivalStats `json:"inter_arrival,omitempty"`
+
+	// Replayed requests aligned with their trace entries, for trace runs
+	Trace *TraceStats `json:"trace,omitempty"`
+
+	// Prompt caching, for providers reporting cached input tokens
+	CachedInputTokens int     `json:"cached_input_tokens,omitempty"`
+	CacheHitRate      float64 `json:"cache_hit_rate,omitempty"` // percent of input tokens served from the prompt cache
+
+	// Reasoning, for reasoning models
+	ReasoningTokens    int     `json:"reasoning_tokens,omitempty"`
+	AvgReasoningTokens float64 `json:"avg_reasoning_tokens,omitempty"` // per successful request
+
+	// Responses cut short by max_tokens, whose capped token counts skew
+	// throughput
+	TruncatedResponses int     `json:"truncated_responses,omitempty"`
+	TruncatedRate      float64 `json:"truncated_rate,omitempty"` // percent of successful requests
+
+	// Successful responses that carried log probabilities, for runs asking
+	// for them
+	LogprobsResponses int `json:"logprobs_responses,omitempty"`
+
+	// Successful responses whose token counts are estimates, see
+	// BenchmarkResult.TokensEstimated
+	EstimatedTokenCounts int `json:"estimated_token_counts,omitempty"`
+
+	// Response size
+	AvgResponseBytes float64 `json:"avg_response_bytes,omitempty"`
+	ByteThroughput   float64 `json:"byte_throughput,omitempty"` // total response bytes / total response time
+
+	// Network phases; DNS, connect and TLS are averaged over the requests
+	// that opened a new connection
+	AvgDNSTime     time.Duration `json:"avg_dns_time,omitempty"`
+	AvgConnectTime time.Duration `json:"avg_connect_time,omitempty"`
+	AvgTLSTime     time.Duration `json:"avg_tls_time,omitempty"`
+	AvgTTFB        time.Duration `json:"avg_ttfb,omitempty"`
 	
 	// Streaming metrics
-	IsStreaming          bool          `json:"is_streaming,omitempty"`
-	AvgTimeToFirstToken  time.Duration `json:"avg_time_to_first_token,omitempty"`
-	MinTimeToFirstToken  time.Duration `json:"min_time_to_first_token,omitempty"`
-	MaxTimeToFirstToken  time.Duration `json:"max_time_to_first_token,omitempty"`
-	AvgTokenThroughput   float64       `json:"avg_token_throughput,omitempty"`
-	MinTokenThroughput   float64       `json:"min_token_throughput,omitempty"`
-	MaxTokenThroughput   float64       `json:"max_token_throughput,omitempty"`
+	IsStreaming            bool          `json:"is_streaming,omitempty"`
+	AvgTimeToFirstToken    time.Duration `json:"avg_time_to_first_token,omitempty"`
+	MinTimeToFirstToken    time.Duration `json:"min_time_to_first_token,omitempty"`
+	MaxTimeToFirstToken    time.Duration `json:"max_time_to_first_token,omitempty"`
+	P50TimeToFirstToken    time.Duration `json:"p50_time_to_first_token,omitempty"`
+	P90TimeToFirstToken    time.Duration `json:"p90_time_to_first_token,omitempty"`
+	P99TimeToFirstToken    time.Duration `json:"p99_time_to_first_token,omitempty"`
+	AvgTokenThroughput     float64       `json:"avg_token_throughput,omitempty"`
+	MinTokenThroughput     float64       `json:"min_token_throughput,omitempty"`
+	MaxTokenThroughput     float64       `json:"max_token_throughput,omitempty"`
+	MedianTokenThroughput  float64       `json:"median_token_throughput,omitempty"`
+	P90TokenThroughput     float64       `json:"p90_token_throughput,omitempty"`
+	OverallTokenThroughput float64       `json:"overall_token_throughput,omitempty"` // total output tokens / total streaming time
+	EmptyResponses         int           `json:"empty_responses,omitempty"`
+}
+
+// InterArrivalStats describes the measured gaps between request starts of
+// a run with a Poisson arrival rate; the coefficient of variation of an
+// exponential distribution is 1
+type InterArrivalStats struct {
+	TargetRate   float64       `json:"target_rate"`   // requests per second
+	ObservedRate float64       `json:"observed_rate"` // requests per second
+	Mean         time.Duration `json:"mean"`
+	StdDev       time.Duration `json:"std_dev"`
+	CV           float64       `json:"cv"` // standard deviation / mean
+}
+
+// TraceStats aligns the requests of a trace replay with their trace
+// entries. Lag is how late a request started compared to its entry's
+// offset, both measured from the first request
+type TraceStats struct {
+	Entries int           `json:"entries"`
+	AvgLag  time.Duration `json:"avg_lag"`
+	MaxLag  time.Duration `json:"max_lag"`
+
+	Slowest []TraceEntryStats `json:"slowest,omitempty"` // slowest replayed entries first
+	Failed  []int             `json:"failed,omitempty"`  // indexes of the entries that failed
+}
+
+// TraceEntryStats is the outcome of replaying one trace entry
+type TraceEntryStats struct {
+	Entry        int           `json:"entry"` // index in the trace, from 0
+	Offset       time.Duration `json:"offset"`
+	Lag          time.Duration `json:"lag"`
+	ResponseTime time.Duration `json:"response_time"`
+}
+
+// TurnStats summarizes response times at one conversation turn depth
+type TurnStats struct {
+	Turn            int           `json:"turn"`
+	Requests        int           `json:"requests"`
+	AvgResponseTime time.Duration `json:"avg_response_time"`
+	P95ResponseTime time.Duration `json:"p95_response_time"`
+}
+
+// KeyStats summarizes the requests sent with one of a provider's API keys
+type KeyStats struct {
+	Key       int     `json:"key"` // index in the provider's keys, api_key first
+	Requests  int     `json:"requests"`
+	Failed    int     `json:"failed"`
+	ErrorRate float64 `json:"error_rate"`
+}
+
+// Conversation is a multi-turn conversation replayed by a benchmark
+type Conversation struct {
+	System string   `yaml:"system"`
+	Turns  []string `yaml:"turns"`
+}
+
+// TestCase pairs a prompt with what a correct response must contain
+type TestCase struct {
+	Prompt        string `yaml:"prompt" json:"prompt"`
+	Expected      string `yaml:"expected,omitempty" json:"expected,omitempty"`             // case-insensitive substring
+	ExpectedRegex string `yaml:"expected_regex,omitempty" json:"expected_regex,omitempty"` // regular expression
+
+	re *regexp.Regexp
+}
+
+// Compile validates the test case and compiles its regular expression
+func (tc *TestCase) Compile() error {
+	if tc.Prompt == "" {
+		return fmt.Errorf("prompt is required")
+	}
+	if tc.Expected == "" && tc.ExpectedRegex == "" {
+		return fmt.Errorf("expected or expected_regex is required")
+	}
+	if tc.ExpectedRegex != "" {
+		re, err := regexp.Compile(tc.ExpectedRegex)
+		if err != nil {
+			return fmt.Errorf("invalid expected_regex: %w", err)
+		}
+		tc.re = re
+	}
+	return nil
+}
+
+// Matches reports whether a response meets every expectation of the test case
+func (tc TestCase) Matches(response string) bool {
+	if tc.Expected != "" && !strings.Contains(strings.ToLower(response), strings.ToLower(tc.Expected)) {
+		return false
+	}
+	if tc.ExpectedRegex != "" {
+		re := tc.re
+		if re == nil {
+			var err error
+			if re, err = regexp.Compile(tc.ExpectedRegex); err != nil {
+				return false
+			}
+		}
+		if !re.MatchString(response) {
+			return false
+		}
+	}
+	return true
 }
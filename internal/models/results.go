@@ -0,0 +1,150 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// ResultsSchemaVersion is the version of the saved results layout, raised
+// whenever older files need migrating to load correctly
+const ResultsSchemaVersion = 1
+
+// BenchmarkResultsFile represents the structure of saved benchmark results
+type BenchmarkResultsFile struct {
+	SchemaVersion int                          `yaml:"schema_version"` // 0 for files saved before versioning
+	Timestamp     time.Time                    `yaml:"timestamp"`
+	Metadata      BenchmarkMetadata            `yaml:"metadata"`
+	Summaries     map[string]BenchmarkSummary  `yaml:"summaries"`
+	Results       map[string][]BenchmarkResult `yaml:"results,omitempty"`
+	Matrix        *MatrixResults               `yaml:"matrix,omitempty"` // set instead of summaries and results by matrix runs
+}
+
+// MatrixResults holds a matrix run: one benchmark per combination of its
+// concurrency and max tokens values
+type MatrixResults struct {
+	Concurrency []int        `yaml:"concurrency" json:"concurrency"`
+	MaxTokens   []int        `yaml:"max_tokens" json:"max_tokens"`
+	Cells       []MatrixCell `yaml:"cells" json:"cells"`
+}
+
+// MatrixCell is the benchmark of one combination of a matrix run
+type MatrixCell struct {
+	Concurrency int                          `yaml:"concurrency" json:"concurrency"`
+	MaxTokens   int                          `yaml:"max_tokens" json:"max_tokens"`
+	Summaries   map[string]BenchmarkSummary  `yaml:"summaries" json:"summaries"`
+	Results     map[string][]BenchmarkResult `yaml:"results,omitempty" json:"results,omitempty"`
+}
+
+// Cell returns the cell for the given combination, or nil if it wasn't run
+func (m *MatrixResults) Cell(concurrency, maxTokens int) *MatrixCell {
+	for i := range m.Cells {
+		if m.Cells[i].Concurrency == concurrency && m.Cells[i].MaxTokens == maxTokens {
+			return &m.Cells[i]
+		}
+	}
+	return nil
+}
+
+// WithoutResults returns a copy of the matrix whose cells only keep their
+// summaries
+func (m *MatrixResults) WithoutResults() *MatrixResults {
+	stripped := *m
+	stripped.Cells = make([]MatrixCell, len(m.Cells))
+	for i, cell := range m.Cells {
+		cell.Results = nil
+		stripped.Cells[i] = cell
+	}
+	return &stripped
+}
+
+// Keys returns every provider/model key found in the matrix, sorted
+func (m *MatrixResults) Keys() []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, cell := range m.Cells {
+		for key := range cell.Summaries {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// BenchmarkMetadata contains information about the benchmark run
+type BenchmarkMetadata struct {
+	Message        string   `yaml:"message"`
+	MessagesFile   string   `yaml:"messages_file,omitempty"`
+	Requests       int      `yaml:"requests"`
+	Concurrency    int      `yaml:"concurrency"`
+	MaxTokens      int      `yaml:"max_tokens"`
+	Streaming      bool     `yaml:"streaming"`
+	ResponseFormat string   `yaml:"response_format,omitempty"`
+	Temperature    *float64 `yaml:"temperature,omitempty"`
+	TopP           *float64 `yaml:"top_p,omitempty"`
+	Seed           *int64   `yaml:"seed,omitempty"`
+
+	Stop             []string `yaml:"stop,omitempty"`
+	FrequencyPenalty *float64 `yaml:"frequency_penalty,omitempty"`
+	PresencePenalty  *float64 `yaml:"presence_penalty,omitempty"`
+	N                int      `yaml:"n,omitempty"`
+	Logprobs         bool     `yaml:"logprobs,omitempty"`
+	TopLogprobs      *int64   `yaml:"top_logprobs,omitempty"`
+
+	ConversationFile  string `yaml:"conversation_file,omitempty"`
+	ConversationTurns int    `yaml:"conversation_turns,omitempty"`
+	TestCasesFile     string `yaml:"test_cases_file,omitempty"`
+	RunID             string `yaml:"run_id,omitempty"`
+	TraceFile         string `yaml:"trace_file,omitempty"`
+	TraceEntries      int    `yaml:"trace_entries,omitempty"`
+	RawBodyFile       string `yaml:"raw_body_file,omitempty"`
+	RandomizedPrompt  bool   `yaml:"randomized_prompt,omitempty"` // a nonce was appended to every prompt
+
+	Images []string `yaml:"images,omitempty"` // image files or URLs sent with every request
+
+	// KeepAlives tells whether connections were reused between requests;
+	// unset in results saved before it was recorded
+	KeepAlives *bool `yaml:"keep_alives,omitempty"`
+}
+
+// NewBenchmarkMetadata describes a run of the given request with the given configuration
+func NewBenchmarkMetadata(request BenchmarkRequest, config BenchmarkConfig) BenchmarkMetadata {
+	keepAlives := !config.DisableKeepAlives
+	metadata := BenchmarkMetadata{
+		Requests:       config.Requests,
+		Concurrency:    config.Concurrency,
+		MaxTokens:      request.MaxTokens,
+		Streaming:      request.Stream,
+		ResponseFormat: request.ResponseFormat,
+		Temperature:    request.Temperature,
+		TopP:           request.TopP,
+		Seed:           request.Seed,
+		RunID:          config.RunID,
+		KeepAlives:     &keepAlives,
+
+		RandomizedPrompt: request.RandomizePrompt,
+
+		Stop:             request.Stop,
+		FrequencyPenalty: request.FrequencyPenalty,
+		PresencePenalty:  request.PresencePenalty,
+		N:                request.N,
+		Logprobs:         request.Logprobs,
+		TopLogprobs:      request.TopLogprobs,
+	}
+
+	// A conversation's messages only hold the system prompt, so its first
+	// turn describes the run better, and so does a trace's first prompt
+	if len(request.Trace) > 0 {
+		metadata.TraceEntries = len(request.Trace)
+		metadata.Message = request.Trace[0].Prompt
+	} else if len(request.Conversation) > 0 {
+		metadata.ConversationTurns = len(request.Conversation)
+		metadata.Message = request.Conversation[0]
+	} else if len(request.Messages) > 0 {
+		metadata.Message = request.Messages[0].Content
+	}
+
+	return metadata
+}
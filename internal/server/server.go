@@ -0,0 +1,176 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"llmbench/internal/httpjson"
+	"llmbench/internal/models"
+	"llmbench/internal/service"
+)
+
+// defaultMaxTokens matches the benchmark command's --max-tokens default
+const defaultMaxTokens = 100
+
+// maxRequestBytes caps the size of a request body, generous enough for long
+// conversations and JSON schemas
+const maxRequestBytes = 10 << 20
+
+// BenchmarkRequest is the body of POST /benchmark: a benchmark request plus
+// overrides for the configured benchmark settings
+type BenchmarkRequest struct {
+	models.BenchmarkRequest
+	Requests    int      `json:"requests,omitempty"`
+	Concurrency int      `json:"concurrency,omitempty"`
+	Providers   []string `json:"providers,omitempty"` // provider names to run, all when empty
+}
+
+// BenchmarkResponse is the body returned by a successful POST /benchmark
+type BenchmarkResponse struct {
+	Summaries map[string]models.BenchmarkSummary `json:"summaries"`
+
+	// Error is set when the run was aborted part way, in which case the
+	// summaries only cover what completed
+	Error string `json:"error,omitempty"`
+}
+
+// Server runs benchmarks on behalf of HTTP clients, one at a time
+type Server struct {
+	config models.BenchmarkConfig
+	busy   chan struct{} // holds a token while a benchmark is running
+}
+
+// New creates a server running benchmarks against the given configuration
+func New(config models.BenchmarkConfig) *Server {
+	return &Server{
+		config: config,
+		busy:   make(chan struct{}, 1),
+	}
+}
+
+// Handler returns the HTTP handler serving the benchmark API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/benchmark", s.handleBenchmark)
+	return mux
+}
+
+// handleBenchmark runs a benchmark and replies with its summaries
+func (s *Server) handleBenchmark(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		httpjson.Error(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	var request BenchmarkRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&request); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			httpjson.Error(w, http.StatusRequestEntityTooLarge, fmt.Errorf("request body exceeds %d bytes", tooLarge.Limit))
+			return
+		}
+		httpjson.Error(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+	if err := request.validate(); err != nil {
+		httpjson.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	if request.MaxTokens == 0 {
+		request.MaxTokens = defaultMaxTokens
+	}
+
+	config, err := s.configFor(request)
+	if err != nil {
+		httpjson.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Benchmarks running side by side would skew each other's latencies
+	select {
+	case s.busy <- struct{}{}:
+		defer func() { <-s.busy }()
+	default:
+		httpjson.Error(w, http.StatusTooManyRequests, fmt.Errorf("a benchmark is already running"))
+		return
+	}
+
+	benchmarkService, err := service.NewBenchmarkService(config)
+	if err != nil {
+		httpjson.Error(w, http.StatusInternalServerError, fmt.Errorf("failed to create benchmark service: %w", err))
+		return
+	}
+	defer benchmarkService.Close()
+
+	run, err := benchmarkService.RunBenchmark(r.Context(), request.BenchmarkRequest, nil)
+	if run == nil {
+		httpjson.Error(w, http.StatusInternalServerError, fmt.Errorf("benchmark failed: %w", err))
+		return
+	}
+
+	response := BenchmarkResponse{
+		Summaries: benchmarkService.GenerateSummary(run),
+	}
+	if err != nil {
+		response.Error = fmt.Sprintf("benchmark aborted: %v", err)
+	}
+	httpjson.Write(w, http.StatusOK, response)
+}
+
+// validate rejects the requests that can't run, before any request is sent
+func (r BenchmarkRequest) validate() error {
+	if len(r.Messages) == 0 && len(r.Prompts) == 0 && len(r.Conversation) == 0 {
+		return fmt.Errorf("one of messages, prompts or conversation is required")
+	}
+	if r.Requests < 0 || r.Concurrency < 0 || r.MaxTokens < 0 {
+		return fmt.Errorf("requests, concurrency and max_tokens cannot be negative")
+	}
+
+	switch r.ResponseFormat {
+	case "", models.ResponseFormatText, models.ResponseFormatJSONObject:
+	case models.ResponseFormatJSONSchema:
+		if r.JSONSchema == nil {
+			return fmt.Errorf("response_format json_schema requires json_schema")
+		}
+	default:
+		return fmt.Errorf("invalid response_format %q: must be text, json_object or json_schema", r.ResponseFormat)
+	}
+	return nil
+}
+
+// configFor applies the request's overrides to the server configuration
+func (s *Server) configFor(request BenchmarkRequest) (models.BenchmarkConfig, error) {
+	config := s.config
+	if request.Requests > 0 {
+		config.Requests = request.Requests
+	}
+	if request.Concurrency > 0 {
+		config.Concurrency = request.Concurrency
+	}
+
+	if len(request.Providers) > 0 {
+		config.Providers = nil
+		for _, name := range request.Providers {
+			provider, ok := s.findProvider(name)
+			if !ok {
+				return config, fmt.Errorf("unknown provider %q", name)
+			}
+			config.Providers = append(config.Providers, provider)
+		}
+	}
+
+	return config, nil
+}
+
+// findProvider looks up a configured provider by name
+func (s *Server) findProvider(name string) (models.Provider, bool) {
+	for _, provider := range s.config.Providers {
+		if provider.Name == name {
+			return provider, true
+		}
+	}
+	return models.Provider{}, false
+}
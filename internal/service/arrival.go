@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+
+	"llmbench/internal/models"
+)
+
+// poissonSchedule returns the start offsets of n requests arriving as a
+// Poisson process with the given mean rate per second, i.e. separated by
+// exponentially distributed gaps
+func poissonSchedule(rate float64, n int) []time.Duration {
+	offsets := make([]time.Duration, n)
+	var elapsed float64
+	for i := range offsets {
+		offsets[i] = time.Duration(elapsed * float64(time.Second))
+		elapsed += rand.ExpFloat64() / rate
+	}
+	return offsets
+}
+
+// traceSchedule returns the start offsets of the entries of a trace,
+// relative to the first one so the replay starts right away
+func traceSchedule(trace []models.TraceEntry) []time.Duration {
+	offsets := make([]time.Duration, len(trace))
+	for i, entry := range trace {
+		offsets[i] = entry.Offset() - trace[0].Offset()
+	}
+	return offsets
+}
+
+// traceSlowest is how many of the slowest replayed entries traceStats keeps
+const traceSlowest = 5
+
+// traceStats aligns the results of a trace replay with their trace entries,
+// measuring how late each request started compared to its entry
+func traceStats(results []models.BenchmarkResult, entries int) *models.TraceStats {
+	var first *models.BenchmarkResult
+	for i := range results {
+		if results[i].StartedAt.IsZero() {
+			continue
+		}
+		if first == nil || results[i].StartedAt.Before(first.StartedAt) {
+			first = &results[i]
+		}
+	}
+
+	stats := &models.TraceStats{Entries: entries}
+	var replayed []models.TraceEntryStats
+	var totalLag time.Duration
+	var started int
+	for _, result := range results {
+		if !result.Success {
+			stats.Failed = append(stats.Failed, result.PromptIndex)
+		}
+		if first == nil || result.StartedAt.IsZero() {
+			continue
+		}
+
+		lag := result.StartedAt.Sub(first.StartedAt) - (result.TraceOffset - first.TraceOffset)
+		if lag < 0 {
+			lag = 0
+		}
+		totalLag += lag
+		started++
+		if lag > stats.MaxLag {
+			stats.MaxLag = lag
+		}
+
+		if result.Success {
+			replayed = append(replayed, models.TraceEntryStats{
+				Entry:        result.PromptIndex,
+				Offset:       result.TraceOffset,
+				Lag:          lag,
+				ResponseTime: result.ResponseTime,
+			})
+		}
+	}
+	sort.Ints(stats.Failed)
+
+	if started > 0 {
+		stats.AvgLag = totalLag / time.Duration(started)
+	}
+
+	sort.Slice(replayed, func(i, j int) bool { return replayed[i].ResponseTime > replayed[j].ResponseTime })
+	if len(replayed) > traceSlowest {
+		replayed = replayed[:traceSlowest]
+	}
+	stats.Slowest = replayed
+
+	return stats
+}
+
+// runScheduled starts run(i) once offsets[i] has elapsed, however many
+// earlier requests are still in flight, and returns once all have finished.
+// Requests due after issueCtx is done are started right away, for run to
+// skip them
+func runScheduled(issueCtx context.Context, offsets []time.Duration, run func(int)) {
+	var wg sync.WaitGroup
+	start := time.Now()
+
+	for i, offset := range offsets {
+		if wait := time.Until(start.Add(offset)); wait > 0 && issueCtx.Err() == nil {
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-issueCtx.Done():
+				timer.Stop()
+			}
+		}
+
+		wg.Add(1)
+		go func(requestNum int) {
+			defer wg.Done()
+			run(requestNum)
+		}(i)
+	}
+
+	wg.Wait()
+}
+
+// interArrivalStats measures the gaps between the start times of the
+// requests of a provider/model, counting each conversation once
+func interArrivalStats(results []models.BenchmarkResult, targetRate float64) *models.InterArrivalStats {
+	var starts []time.Time
+	for _, result := range results {
+		if result.Turn <= 1 && !result.StartedAt.IsZero() {
+			starts = append(starts, result.StartedAt)
+		}
+	}
+	if len(starts) < 2 {
+		return nil
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+
+	gaps := make([]float64, len(starts)-1)
+	var sum float64
+	for i := range gaps {
+		gaps[i] = starts[i+1].Sub(starts[i]).Seconds()
+		sum += gaps[i]
+	}
+	mean := sum / float64(len(gaps))
+
+	var variance float64
+	for _, gap := range gaps {
+		variance += (gap - mean) * (gap - mean)
+	}
+	stdDev := math.Sqrt(variance / float64(len(gaps)))
+
+	stats := &models.InterArrivalStats{
+		TargetRate: targetRate,
+		Mean:       time.Duration(mean * float64(time.Second)),
+		StdDev:     time.Duration(stdDev * float64(time.Second)),
+	}
+	if mean > 0 {
+		stats.ObservedRate = 1 / mean
+		stats.CV = stdDev / mean
+	}
+	return stats
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"llmbench/internal/models"
+	"llmbench/internal/units"
+)
+
+// MetricChange compares one metric of a summary with its baseline value
+type MetricChange struct {
+	Metric     string
+	Baseline   string
+	Current    string
+	Change     float64 // percent change from the baseline, or points for rates
+	Unit       string  // "%" or "pts"
+	Regression bool    // the change is worse than the allowed regression
+}
+
+// BaselineResult compares a provider/model's summary with its baseline
+type BaselineResult struct {
+	Key     string
+	Missing bool // the baseline has no summary for this provider/model
+	Changes []MetricChange
+}
+
+// Regressions lists the metrics that regressed past the allowed threshold
+func (r BaselineResult) Regressions() []MetricChange {
+	var regressions []MetricChange
+	for _, change := range r.Changes {
+		if change.Regression {
+			regressions = append(regressions, change)
+		}
+	}
+	return regressions
+}
+
+// CompareBaseline compares every summary with the baseline summary of the
+// same provider/model. A latency that grows, or a throughput that drops, by
+// more than maxRegression percent is a regression, as is an error rate that
+// grows by more than maxRegression points
+func CompareBaseline(summaries, baseline map[string]models.BenchmarkSummary, maxRegression float64) []BaselineResult {
+	var results []BaselineResult
+	for key, summary := range summaries {
+		base, ok := baseline[key]
+		if !ok {
+			results = append(results, BaselineResult{Key: key, Missing: true})
+			continue
+		}
+		results = append(results, BaselineResult{Key: key, Changes: compareSummary(summary, base, maxRegression)})
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Key < results[j].Key })
+	return results
+}
+
+// compareSummary lists the changes of the metrics set in both summaries
+func compareSummary(summary, base models.BenchmarkSummary, maxRegression float64) []MetricChange {
+	var changes []MetricChange
+
+	latency := func(metric string, current, baseline time.Duration) {
+		if current <= 0 || baseline <= 0 {
+			return
+		}
+		change := percentChange(float64(current), float64(baseline))
+		changes = append(changes, MetricChange{
+			Metric:     metric,
+			Baseline:   units.Duration(baseline),
+			Current:    units.Duration(current),
+			Change:     change,
+			Unit:       "%",
+			Regression: change > maxRegression,
+		})
+	}
+	latency("avg latency", summary.AvgResponseTime, base.AvgResponseTime)
+	latency("p50 latency", summary.P50ResponseTime, base.P50ResponseTime)
+	latency("p95 latency", summary.P95ResponseTime, base.P95ResponseTime)
+	latency("p99 latency", summary.P99ResponseTime, base.P99ResponseTime)
+	latency("avg TTFT", summary.AvgTimeToFirstToken, base.AvgTimeToFirstToken)
+
+	// Error rates are often 0, so they change by points rather than percent
+	errorChange := summary.ErrorRate - base.ErrorRate
+	changes = append(changes, MetricChange{
+		Metric:     "error rate",
+		Baseline:   fmt.Sprintf("%.2f%%", base.ErrorRate),
+		Current:    fmt.Sprintf("%.2f%%", summary.ErrorRate),
+		Change:     errorChange,
+		Unit:       "pts",
+		Regression: errorChange > maxRegression,
+	})
+
+	if summary.AvgTokenThroughput > 0 && base.AvgTokenThroughput > 0 {
+		change := percentChange(summary.AvgTokenThroughput, base.AvgTokenThroughput)
+		changes = append(changes, MetricChange{
+			Metric:     "throughput",
+			Baseline:   fmt.Sprintf("%.2f tokens/sec", base.AvgTokenThroughput),
+			Current:    fmt.Sprintf("%.2f tokens/sec", summary.AvgTokenThroughput),
+			Change:     change,
+			Unit:       "%",
+			Regression: change < -maxRegression,
+		})
+	}
+
+	return changes
+}
+
+// percentChange returns the change from baseline to current, in percent
+func percentChange(current, baseline float64) float64 {
+	return (current - baseline) / baseline * 100
+}
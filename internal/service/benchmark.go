@@ -2,20 +2,50 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
+	"text/template"
 	"time"
 
 	"llmbench/internal/models"
+	"llmbench/internal/telemetry"
 )
 
 // BenchmarkService orchestrates benchmark tests across multiple providers
 type BenchmarkService struct {
-	providers []models.Provider
-	config    models.BenchmarkConfig
-	timeout   time.Duration
+	providers         []models.Provider
+	config            models.BenchmarkConfig
+	timeout           time.Duration
+	providerTimeouts  map[string]time.Duration // per-provider overrides of timeout
+	promptTemplates   map[string]*template.Template
+	connectionTimeout time.Duration
+	tracer            *telemetry.Tracer
+	statsd            *telemetry.StatsD
+	maxDuration       time.Duration
+	stallTimeout      time.Duration
+
+	// sampleSink receives the streamed text of each provider/model's first
+	// request, for live previews
+	sampleSink func(key, chunk string)
+
+	// Bearer token sources of the providers with token_auth, by name
+	tokenSources   map[string]*tokenSource
+	tokenSourcesMu sync.Mutex
+
 }
 
+// defaultConnectionTimeout is used when the config sets no connection_timeout
+const defaultConnectionTimeout = 60 * time.Second
+
 // NewBenchmarkService creates a new benchmark service
 func NewBenchmarkService(config models.BenchmarkConfig) (*BenchmarkService, error) {
 	timeout, err := time.ParseDuration(config.Timeout)
@@ -23,16 +53,93 @@ func NewBenchmarkService(config models.BenchmarkConfig) (*BenchmarkService, erro
 		return nil, fmt.Errorf("invalid timeout duration: %w", err)
 	}
 
+	connectionTimeout := defaultConnectionTimeout
+	if config.ConnectionTimeout != "" {
+		connectionTimeout, err = time.ParseDuration(config.ConnectionTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid connection timeout duration: %w", err)
+		}
+	}
+
+	var maxDuration time.Duration
+	if config.MaxDuration != "" {
+		maxDuration, err = time.ParseDuration(config.MaxDuration)
+		if err != nil {
+			return nil, fmt.Errorf("invalid max duration: %w", err)
+		}
+	}
+
+	var stallTimeout time.Duration
+	if config.StreamStallTimeout != "" {
+		stallTimeout, err = time.ParseDuration(config.StreamStallTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid stream stall timeout: %w", err)
+		}
+	}
+
+	providerTimeouts := make(map[string]time.Duration)
+	promptTemplates := make(map[string]*template.Template)
+	for _, provider := range config.Providers {
+		tmpl, err := provider.ParsePromptTemplate()
+		if err != nil {
+			return nil, fmt.Errorf("invalid prompt template for provider %s: %w", provider.Name, err)
+		}
+		if tmpl != nil {
+			promptTemplates[provider.Name] = tmpl
+		}
+		if provider.Timeout != "" {
+			providerTimeouts[provider.Name], err = time.ParseDuration(provider.Timeout)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timeout duration for provider %s: %w", provider.Name, err)
+			}
+		}
+	}
+
 	return &BenchmarkService{
-		providers: config.Providers,
-		config:    config,
-		timeout:   timeout,
+		providers:         config.Providers,
+		config:            config,
+		timeout:           timeout,
+		providerTimeouts:  providerTimeouts,
+		promptTemplates:   promptTemplates,
+		connectionTimeout: connectionTimeout,
+		tracer:            telemetry.NewTracer(config.Telemetry),
+		statsd:            telemetry.NewStatsD(config.StatsDAddr),
+		maxDuration:       maxDuration,
+		stallTimeout:      stallTimeout,
+		tokenSources:      make(map[string]*tokenSource),
 	}, nil
 }
 
+// Close releases the service's connection to the StatsD agent, if any
+func (bs *BenchmarkService) Close() error {
+	return bs.statsd.Close()
+}
+
+// SetSampleSink sets a function receiving the text streamed by the first
+// request of every provider/model; nil disables it
+func (bs *BenchmarkService) SetSampleSink(sink func(key, chunk string)) {
+	bs.sampleSink = sink
+}
+
+// ConnectionResult is the outcome of a provider's connection test
+type ConnectionResult struct {
+	Err     error
+	Latency time.Duration // time the test took, retries included
+}
+
 // TestConnections tests connectivity to all configured providers
 func (bs *BenchmarkService) TestConnections(ctx context.Context) map[string]error {
 	results := make(map[string]error)
+	for provider, result := range bs.CheckConnections(ctx) {
+		results[provider] = result.Err
+	}
+	return results
+}
+
+// CheckConnections tests connectivity to all configured providers, timing
+// each test
+func (bs *BenchmarkService) CheckConnections(ctx context.Context) map[string]ConnectionResult {
+	results := make(map[string]ConnectionResult)
 	var mu sync.Mutex
 	var wg sync.WaitGroup
 
@@ -41,9 +148,107 @@ func (bs *BenchmarkService) TestConnections(ctx context.Context) map[string]erro
 		go func(p models.Provider) {
 			defer wg.Done()
 			
-			service := NewOpenAIService(p, bs.timeout)
-			err := service.TestConnection(ctx)
+			start := time.Now()
+			err := testConnectionSafely(ctx, bs.tagged(p), bs.connectionTimeout, bs.tokenSourceFor(p))
+			latency := time.Since(start)
 			
+			mu.Lock()
+			results[p.Name] = ConnectionResult{Err: err, Latency: latency}
+			mu.Unlock()
+		}(provider)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// testConnectionSafely tests a provider's connection, reporting a panic as
+// a connection failure
+func testConnectionSafely(ctx context.Context, provider models.Provider, timeout time.Duration, tokens *tokenSource) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	
+	return newOpenAIService(provider, timeout, tokens).TestConnection(ctx)
+}
+
+// timeoutFor returns the request timeout of a provider, which may override
+// the benchmark's
+func (bs *BenchmarkService) timeoutFor(provider models.Provider) time.Duration {
+	if timeout, ok := bs.providerTimeouts[provider.Name]; ok {
+		return timeout
+	}
+	return bs.timeout
+}
+
+// concurrencyFor returns the concurrency of a provider, which may override
+// the benchmark's
+func (bs *BenchmarkService) concurrencyFor(provider models.Provider) int {
+	if provider.Concurrency > 0 {
+		return provider.Concurrency
+	}
+	return bs.config.Concurrency
+}
+
+// IdleWorkers returns the concurrency of the providers running more
+// workers per model than there are requests, by name: the extra workers
+// would sit idle. A trace or an arrival rate starts requests on its own
+// schedule, without workers
+func (bs *BenchmarkService) IdleWorkers(request models.BenchmarkRequest) map[string]int {
+	idle := make(map[string]int)
+	if len(request.Trace) > 0 || bs.config.ArrivalRate > 0 {
+		return idle
+	}
+	for _, provider := range bs.providers {
+		if concurrency := bs.concurrencyFor(provider); concurrency > bs.config.Requests {
+			idle[provider.Name] = concurrency
+		}
+	}
+	return idle
+}
+
+// tagged returns the provider with the configured user agent and run id
+// added to its headers, leaving headers the provider sets itself alone, and
+// with keep-alives disabled if the benchmark asks for fresh connections
+func (bs *BenchmarkService) tagged(provider models.Provider) models.Provider {
+	provider.DisableKeepAlives = bs.config.DisableKeepAlives
+	if bs.config.UserAgent == "" && bs.config.RunID == "" {
+		return provider
+	}
+
+	headers := make(map[string]string, len(provider.Headers)+2)
+	set := make(map[string]bool, len(provider.Headers))
+	for key, value := range provider.Headers {
+		headers[key] = value
+		set[http.CanonicalHeaderKey(key)] = true
+	}
+	if bs.config.UserAgent != "" && !set["User-Agent"] {
+		headers["User-Agent"] = bs.config.UserAgent
+	}
+	if bs.config.RunID != "" && !set[models.RunIDHeader] {
+		headers[models.RunIDHeader] = bs.config.RunID
+	}
+
+	provider.Headers = headers
+	return provider
+}
+
+// VerifyModels checks that every configured model is listed by its provider
+func (bs *BenchmarkService) VerifyModels(ctx context.Context) map[string]error {
+	results := make(map[string]error)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, provider := range bs.providers {
+		wg.Add(1)
+		go func(p models.Provider) {
+			defer wg.Done()
+
+			service := newOpenAIService(bs.tagged(p), bs.timeoutFor(p), bs.tokenSourceFor(p))
+			err := service.VerifyModels(ctx)
+
 			mu.Lock()
 			results[p.Name] = err
 			mu.Unlock()
@@ -54,83 +259,627 @@ func (bs *BenchmarkService) TestConnections(ctx context.Context) map[string]erro
 	return results
 }
 
+// EffectiveRequest is the body of the first request a provider/model is
+// sent, or the error building it
+type EffectiveRequest struct {
+	Key  string
+	Body []byte
+	Err  error
+}
+
+// EffectiveRequests returns the body of the first request every
+// provider/model would be sent, with the first turn of a conversation and,
+// when prompts are randomized, an example nonce
+func (bs *BenchmarkService) EffectiveRequests(request models.BenchmarkRequest) []EffectiveRequest {
+	var requests []EffectiveRequest
+	for _, provider := range bs.providers {
+		service := newOpenAIService(bs.tagged(provider), bs.timeoutFor(provider), bs.tokenSourceFor(provider))
+		for _, model := range provider.Models {
+			providerRequest, _ := requestFor(request, model, 0)
+			providerRequest, err := bs.withPromptTemplate(providerRequest, provider.Name)
+			if err != nil {
+				requests = append(requests, EffectiveRequest{Key: fmt.Sprintf("%s/%s", provider.Name, model), Err: err})
+				continue
+			}
+			if len(request.Conversation) > 0 {
+				turn := providerRequest.Conversation[0]
+				if request.RandomizePrompt {
+					turn += " " + newNonce()
+				}
+				providerRequest.Messages = append(slices.Clone(providerRequest.Messages), models.ChatMessage{Role: "user", Content: turn})
+			} else if request.RandomizePrompt {
+				providerRequest.Messages = withNonce(providerRequest.Messages, newNonce())
+			}
+
+			body, err := service.RequestBody(providerRequest)
+			requests = append(requests, EffectiveRequest{
+				Key:  fmt.Sprintf("%s/%s", provider.Name, model),
+				Body: body,
+				Err:  err,
+			})
+		}
+	}
+	return requests
+}
+
+// Run is the outcome of a benchmark run: the results of every
+// provider/model, and what the run knows of the requests that produced none
+type Run struct {
+	Results map[string][]models.BenchmarkResult
+
+	// Requests per provider/model that the run planned, and those it never
+	// started because it ran out of time, or gave up after consecutive
+	// failures
+	Planned         map[string]int
+	NotStarted      map[string]int
+	FailFastSkipped map[string]int
+
+	// TraceEntries is the number of entries in the trace the run replayed,
+	// if any, to align results with
+	TraceEntries int
+}
+
 // RunBenchmark executes benchmark tests for all providers and their models
-func (bs *BenchmarkService) RunBenchmark(ctx context.Context, request models.BenchmarkRequest, progressCallback func(string, int, int)) (map[string][]models.BenchmarkResult, error) {
-	results := make(map[string][]models.BenchmarkResult)
+func (bs *BenchmarkService) RunBenchmark(ctx context.Context, request models.BenchmarkRequest, progressCallback func(string, int, int)) (*Run, error) {
+	return bs.RunBenchmarkFor(ctx, bs.providers, request, progressCallback)
+}
+
+// RunBenchmarkFor executes benchmark tests for the given subset of providers
+// and their models
+func (bs *BenchmarkService) RunBenchmarkFor(ctx context.Context, providers []models.Provider, request models.BenchmarkRequest, progressCallback func(string, int, int)) (*Run, error) {
+	return bs.runBenchmark(ctx, providers, request, 0, progressCallback)
+}
+
+// runBenchmark executes benchmark tests for the given providers, sending
+// up to concurrency requests at a time to each provider/model, or up to
+// each provider's own concurrency when concurrency is 0
+func (bs *BenchmarkService) runBenchmark(ctx context.Context, providers []models.Provider, request models.BenchmarkRequest, concurrency int, progressCallback func(string, int, int)) (*Run, error) {
+	run := &Run{
+		Results:         make(map[string][]models.BenchmarkResult),
+		Planned:         make(map[string]int),
+		NotStarted:      make(map[string]int),
+		FailFastSkipped: make(map[string]int),
+		TraceEntries:    len(request.Trace),
+	}
+	results := run.Results
 	var mu sync.Mutex
 	var wg sync.WaitGroup
 
-	for _, provider := range bs.providers {
+	// Too many timeouts in a row cancel everything, in flight or not
+	ctx, abort := context.WithCancelCause(ctx)
+	defer abort(nil)
+	breaker := newTimeoutBreaker(bs.config.AbortAfterTimeouts, abort)
+
+	// Providers past MaxParallelProviders wait for a running one to finish
+	var providerSlots chan struct{}
+	if bs.config.MaxParallelProviders > 0 {
+		providerSlots = make(chan struct{}, bs.config.MaxParallelProviders)
+	}
+
+	for _, provider := range providers {
+		var providerWg sync.WaitGroup
+		if providerSlots != nil {
+			providerSlots <- struct{}{}
+		}
+		
 		for _, model := range provider.Models {
 			wg.Add(1)
+			providerWg.Add(1)
 			go func(p models.Provider, m string) {
 				defer wg.Done()
+				defer providerWg.Done()
 				
 				// Create a unique key for provider/model combination
 				providerModelKey := fmt.Sprintf("%s/%s", p.Name, m)
 				
-				providerResults := bs.runProviderModelBenchmark(ctx, p, m, request, progressCallback)
+				// A crash while setting up one provider/model must not sink
+				// the whole run, so it is recorded as fully failed instead
+				defer func() {
+					if r := recover(); r != nil {
+						fmt.Fprintf(os.Stderr, "⚠️  WARNING: %s failed: panic: %v\n", providerModelKey, r)
+						failed := failedResults(p.Name, m, bs.config.Requests, fmt.Sprintf("panic: %v", r))
+						mu.Lock()
+						results[providerModelKey] = failed
+						mu.Unlock()
+					}
+				}()
+				
+				providerConcurrency := concurrency
+				if providerConcurrency == 0 {
+					providerConcurrency = bs.concurrencyFor(p)
+				}
+				
+				// Requests rotate round-robin over the provider's keys, one
+				// client each, whose connections are opened once the
+				// provider has its slot
+				services := bs.newServices(p)
+				if bs.config.PrewarmConnections {
+					prewarm(ctx, services)
+				}
+				
+				// Once the time budget is spent no new requests are started,
+				// while those already in flight are left to finish. It starts
+				// with the provider's slot, so queued providers get all of it
+				issueCtx := ctx
+				if bs.maxDuration > 0 {
+					var cancel context.CancelFunc
+					issueCtx, cancel = context.WithTimeout(ctx, bs.maxDuration)
+					defer cancel()
+				}
+				
+				providerResults, notStarted, skipped := bs.runProviderModelBenchmark(ctx, issueCtx, services, p, m, request, providerConcurrency, breaker, progressCallback)
 				
 				mu.Lock()
 				results[providerModelKey] = providerResults
+				run.Planned[providerModelKey] = plannedResults(bs.config.Requests, request)
+				if notStarted > 0 || skipped > 0 {
+					run.NotStarted[providerModelKey] = notStarted
+					run.FailFastSkipped[providerModelKey] = skipped
+				}
 				mu.Unlock()
 			}(provider, model)
 		}
+		
+		if providerSlots != nil {
+			go func() {
+				providerWg.Wait()
+				<-providerSlots
+			}()
+		}
 	}
 
 	wg.Wait()
-	return results, nil
+
+	// Results are saved with their outliers flagged
+	MarkOutliers(results)
+
+	// Spans are exported once the run is over so exporting never competes
+	// with the requests being measured
+	if err := bs.tracer.Flush(context.WithoutCancel(ctx)); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  WARNING: %v\n", err)
+	}
+
+	// What completed before the breaker tripped is still worth reporting,
+	// along with the provider/models it cut short
+	if cause := context.Cause(ctx); errors.Is(cause, errProvidersUnreachable) {
+		var cut []string
+		for _, key := range slices.Sorted(maps.Keys(run.NotStarted)) {
+			if run.NotStarted[key] > 0 {
+				cut = append(cut, key)
+			}
+		}
+		if len(cut) > 0 {
+			return run, fmt.Errorf("%w; cut short: %s", cause, strings.Join(cut, ", "))
+		}
+		return run, cause
+	}
+	return run, nil
 }
 
-// runProviderModelBenchmark runs benchmark for a single provider/model combination
-func (bs *BenchmarkService) runProviderModelBenchmark(ctx context.Context, provider models.Provider, model string, request models.BenchmarkRequest, progressCallback func(string, int, int)) []models.BenchmarkResult {
-	service := NewOpenAIService(provider, bs.timeout)
-	results := make([]models.BenchmarkResult, 0, bs.config.Requests)
+// plannedResults returns the results a provider/model is meant to produce:
+// a trace sets the number of requests, and each conversation replay
+// produces one result per turn
+func plannedResults(requests int, request models.BenchmarkRequest) int {
+	if len(request.Trace) > 0 {
+		requests = len(request.Trace)
+	}
+	if len(request.Conversation) > 0 {
+		requests *= len(request.Conversation)
+	}
+	return requests
+}
+
+// newServices returns the clients the requests to a provider rotate over,
+// one per API key
+func (bs *BenchmarkService) newServices(provider models.Provider) []*OpenAIService {
+	var services []*OpenAIService
+	for i := range max(1, len(provider.Keys())) {
+		keyProvider := provider
+		if len(provider.Keys()) > 1 {
+			keyProvider = provider.WithKey(i)
+		}
+		service := newOpenAIService(bs.tagged(keyProvider), bs.timeoutFor(provider), bs.tokenSourceFor(provider))
+		service.SetStallTimeout(bs.stallTimeout)
+		service.SetStrictResponses(bs.config.StrictResponses)
+		services = append(services, service)
+	}
+	return services
+}
+
+// keySchedule returns the order in which requests rotate over a provider's
+// clients, by index: round-robin, or in proportion to its key weights.
+// Smooth weighted round-robin interleaves the keys rather than sending a
+// heavy key its whole share in a burst
+func keySchedule(provider models.Provider, clients int) []int {
+	if len(provider.KeyWeights) != clients {
+		schedule := make([]int, clients)
+		for i := range schedule {
+			schedule[i] = i
+		}
+		return schedule
+	}
+
+	total := 0
+	for _, weight := range provider.KeyWeights {
+		total += weight
+	}
+	current := make([]int, clients)
+	schedule := make([]int, 0, total)
+	for range total {
+		next := 0
+		for i, weight := range provider.KeyWeights {
+			current[i] += weight
+			if current[i] > current[next] {
+				next = i
+			}
+		}
+		current[next] -= total
+		schedule = append(schedule, next)
+	}
+	return schedule
+}
+
+// prewarm opens a connection for every client, all at once, each client
+// keeping its own connections
+func prewarm(ctx context.Context, services []*OpenAIService) {
+	var wg sync.WaitGroup
+	for _, service := range services {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := service.Prewarm(ctx); err != nil {
+				fmt.Fprintf(os.Stderr, "⚠️  WARNING: failed to prewarm a connection to %s: %v\n", service.provider.Name, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// runProviderModelBenchmark runs benchmark for a single provider/model
+// combination through the given clients, starting requests until issueCtx
+// is done or too many failed in a row, and returns the results along with
+// the number of requests that were never started for either reason. Every
+// result is fed to breaker
+func (bs *BenchmarkService) runProviderModelBenchmark(ctx, issueCtx context.Context, services []*OpenAIService, provider models.Provider, model string, request models.BenchmarkRequest, concurrency int, breaker *timeoutBreaker, progressCallback func(string, int, int)) ([]models.BenchmarkResult, int, int) {
+	// Bearer tokens are fetched before the run so the first request doesn't
+	// pay for it; without one, every request would fail the same way
+	if err := services[0].FetchToken(issueCtx); err != nil {
+		results := failedResults(provider.Name, model, plannedResults(bs.config.Requests, request), err.Error())
+		for i := range results {
+			results[i].ErrorKind = models.ErrorKindAuth
+		}
+		return results, 0, 0
+	}
+	
+	requestCount := bs.config.Requests
+	if len(request.Trace) > 0 {
+		requestCount = len(request.Trace)
+	}
+	totalResults := plannedResults(bs.config.Requests, request)
+	results := make([]models.BenchmarkResult, 0, totalResults)
 	
-	// Create semaphore for concurrency control
-	semaphore := make(chan struct{}, bs.config.Concurrency)
 	var wg sync.WaitGroup
 	var mu sync.Mutex
+	notStarted := 0
 	
 	// Create a unique identifier for progress tracking
 	providerModelKey := fmt.Sprintf("%s/%s", provider.Name, model)
+	keys := keySchedule(provider, len(services))
 	
-	for i := 0; i < bs.config.Requests; i++ {
-		wg.Add(1)
-		go func(requestNum int) {
-			defer wg.Done()
-			
-			// Acquire semaphore
-			semaphore <- struct{}{}
-			defer func() { <-semaphore }()
-			
-			// Update request model to use the specific model
-			providerRequest := request
-			providerRequest.Model = model
-			
-			var result models.BenchmarkResult
-			if providerRequest.Stream {
-				result = service.SendChatCompletionStream(ctx, providerRequest)
-			} else {
-				result = service.SendChatCompletion(ctx, providerRequest)
-			}
-			
+	// With fail-fast, a provider/model that failed that many requests in a
+	// row gets no more requests. Requests that are never started are only
+	// counted, not recorded as results, so they don't weigh on latencies
+	// and error rates, yet they count towards progress so that it reaches
+	// the planned total
+	consecutiveFailures := 0
+	skipped := 0
+	reportProgress := func() {
+		if progressCallback != nil {
+			progressCallback(providerModelKey, len(results)+notStarted+skipped, totalResults)
+		}
+	}
+	
+	runRequest := func(requestNum int) {
+		if issueCtx.Err() != nil {
 			mu.Lock()
+			notStarted += max(1, len(request.Conversation))
+			reportProgress()
+			mu.Unlock()
+			return
+		}
+		
+		mu.Lock()
+		if bs.config.FailFast > 0 && consecutiveFailures >= bs.config.FailFast {
+			skipped += max(1, len(request.Conversation))
+			reportProgress()
+			mu.Unlock()
+			return
+		}
+		mu.Unlock()
+		
+		providerRequest, promptIndex := requestFor(request, model, requestNum)
+		providerRequest, templateErr := bs.withPromptTemplate(providerRequest, provider.Name)
+		
+		// A nonce makes the prompt unique, and is left out of grading in
+		// case the model echoes it
+		nonce := ""
+		if request.RandomizePrompt && len(request.Conversation) == 0 {
+			nonce = newNonce()
+			providerRequest.Messages = withNonce(providerRequest.Messages, nonce)
+		}
+		
+		// The first request's stream feeds the live sample preview
+		var onChunk func(string)
+		if requestNum == 0 && bs.sampleSink != nil {
+			sink := bs.sampleSink
+			onChunk = func(chunk string) { sink(providerModelKey, chunk) }
+		}
+		
+		keyIndex := keys[requestNum%len(keys)]
+		var requestResults []models.BenchmarkResult
+		if templateErr != nil {
+			requestResults = failedResults(provider.Name, model, max(1, len(request.Conversation)), fmt.Sprintf("prompt template: %v", templateErr))
+		} else {
+			requestResults = sendSafely(ctx, services[keyIndex], providerRequest, onChunk)
+		}
+		
+		// Only responses can be graded, failures already count as errors
+		if len(request.TestCases) > 0 {
+			for i := range requestResults {
+				if requestResults[i].Success {
+					requestResults[i].Graded = true
+					response := requestResults[i].Response
+					if nonce != "" {
+						response = strings.ReplaceAll(response, nonce, "")
+					}
+					requestResults[i].Correct = request.TestCases[promptIndex].Matches(response)
+				}
+			}
+		}
+		
+		mu.Lock()
+		failed := false
+		for _, result := range requestResults {
+			failed = failed || !result.Success
+			result.PromptIndex = promptIndex
+			result.Index = requestNum
+			result.KeyIndex = keyIndex
+			if len(request.Trace) > 0 {
+				result.TraceOffset = request.Trace[requestNum].Offset()
+			}
+			bs.tracer.RecordRequest(result)
+			bs.statsd.RecordRequest(result)
+			breaker.record(result)
 			results = append(results, result)
-			if progressCallback != nil {
-				progressCallback(providerModelKey, len(results), bs.config.Requests)
+			reportProgress()
+		}
+		if failed {
+			consecutiveFailures++
+		} else {
+			consecutiveFailures = 0
+		}
+		mu.Unlock()
+	}
+	
+	// An open loop starts requests at the trace's cadence or on a Poisson
+	// schedule instead of waiting for in-flight requests to finish
+	if len(request.Trace) > 0 {
+		runScheduled(issueCtx, traceSchedule(request.Trace), runRequest)
+		return results, notStarted, skipped
+	}
+	if bs.config.ArrivalRate > 0 {
+		runScheduled(issueCtx, poissonSchedule(bs.config.ArrivalRate, requestCount), runRequest)
+		return results, notStarted, skipped
+	}
+	
+	// A fixed pool of Concurrency workers pulls request numbers from a
+	// channel, so memory stays flat however many requests are run. Without
+	// a worker the first send would block forever
+	jobs := make(chan int)
+	for w := 0; w < max(1, concurrency); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for requestNum := range jobs {
+				runRequest(requestNum)
 			}
-			mu.Unlock()
-		}(i)
+		}()
+	}
+	
+	for i := 0; i < requestCount; i++ {
+		jobs <- i
 	}
+	close(jobs)
 	
 	wg.Wait()
+	return results, notStarted, skipped
+}
+
+// requestFor returns the request sent as the requestNum-th request to a
+// model, along with the index of its prompt
+func requestFor(request models.BenchmarkRequest, model string, requestNum int) (models.BenchmarkRequest, int) {
+	// Update request model to use the specific model
+	providerRequest := request
+	providerRequest.Model = model
+	
+	// Replay the trace entry, or round-robin through the test cases or
+	// prompts if several were provided
+	promptIndex := 0
+	if len(request.Trace) > 0 {
+		promptIndex = requestNum
+		providerRequest.Messages = []models.ChatMessage{
+			{
+				Role:    "user",
+				Content: request.Trace[requestNum].Prompt,
+			},
+		}
+	} else if len(request.TestCases) > 0 {
+		promptIndex = requestNum % len(request.TestCases)
+		providerRequest.Messages = []models.ChatMessage{
+			{
+				Role:    "user",
+				Content: request.TestCases[promptIndex].Prompt,
+			},
+		}
+	} else if len(request.Prompts) > 0 {
+		promptIndex = requestNum % len(request.Prompts)
+		providerRequest.Messages = []models.ChatMessage{
+			{
+				Role:    "user",
+				Content: request.Prompts[promptIndex],
+			},
+		}
+	}
+	
+	return providerRequest, promptIndex
+}
+
+// withPromptTemplate wraps the prompt of a request, or every turn of a
+// conversation, in the provider's prompt template if it has one. Other
+// messages, such as a system prompt, are sent as they are
+func (bs *BenchmarkService) withPromptTemplate(request models.BenchmarkRequest, provider string) (models.BenchmarkRequest, error) {
+	tmpl := bs.promptTemplates[provider]
+	if tmpl == nil {
+		return request, nil
+	}
+	
+	render := func(message string) (string, error) {
+		var b strings.Builder
+		err := tmpl.Execute(&b, models.PromptData{Message: message, Provider: provider, Model: request.Model})
+		return b.String(), err
+	}
+	
+	if len(request.Conversation) > 0 {
+		turns := make([]string, len(request.Conversation))
+		for i, turn := range request.Conversation {
+			rendered, err := render(turn)
+			if err != nil {
+				return request, err
+			}
+			turns[i] = rendered
+		}
+		request.Conversation = turns
+		return request, nil
+	}
+	
+	messages := slices.Clone(request.Messages)
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" {
+			rendered, err := render(messages[i].Content)
+			if err != nil {
+				return request, err
+			}
+			messages[i].Content = rendered
+			break
+		}
+	}
+	request.Messages = messages
+	return request, nil
+}
+
+// sendSafely sends a request, or replays a conversation, turning a panic
+// into a failed result so the rest of the run carries on
+func sendSafely(ctx context.Context, service *OpenAIService, request models.BenchmarkRequest, onChunk func(string)) (results []models.BenchmarkResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			results = failedResults(service.provider.Name, request.Model, 1, fmt.Sprintf("panic: %v", r))
+		}
+	}()
+	
+	if len(request.Conversation) > 0 {
+		return runConversation(ctx, service, request)
+	}
+	if request.Stream && onChunk != nil {
+		return []models.BenchmarkResult{service.StreamChatCompletion(ctx, request, onChunk)}
+	}
+	return []models.BenchmarkResult{sendRequest(ctx, service, request)}
+}
+
+// failedResults builds count failed results carrying the same error
+func failedResults(provider, model string, count int, message string) []models.BenchmarkResult {
+	results := make([]models.BenchmarkResult, count)
+	for i := range results {
+		results[i] = models.BenchmarkResult{
+			Provider:  provider,
+			ModelName: model,
+			Index:     i,
+			StartedAt: time.Now(),
+			Error:     message,
+			ErrorKind: models.ErrorKindOther,
+		}
+	}
 	return results
 }
 
-// GenerateSummary creates a summary of benchmark results
-func (bs *BenchmarkService) GenerateSummary(results map[string][]models.BenchmarkResult) map[string]models.BenchmarkSummary {
+// sendRequest sends a single request in streaming or non-streaming mode
+func sendRequest(ctx context.Context, service *OpenAIService, request models.BenchmarkRequest) models.BenchmarkResult {
+	if request.Stream {
+		return service.SendChatCompletionStream(ctx, request)
+	}
+	return service.SendChatCompletion(ctx, request)
+}
+
+// runConversation replays a conversation turn by turn, feeding each
+// assistant response back into the message history of the next turn
+func runConversation(ctx context.Context, service *OpenAIService, request models.BenchmarkRequest) []models.BenchmarkResult {
+	results := make([]models.BenchmarkResult, 0, len(request.Conversation))
+	messages := append([]models.ChatMessage{}, request.Messages...)
+	
+	for i, turn := range request.Conversation {
+		// Without the previous reply the rest of the conversation can't be
+		// replayed, so the remaining turns are recorded as failures
+		if i > 0 && !results[i-1].Success {
+			results = append(results, models.BenchmarkResult{
+				Provider:  service.provider.Name,
+				ModelName: request.Model,
+				Turn:      i + 1,
+				Error:     fmt.Sprintf("skipped: turn %d failed", i),
+				ErrorKind: models.ErrorKindSkipped,
+			})
+			continue
+		}
+		
+		if request.RandomizePrompt {
+			turn += " " + newNonce()
+		}
+		messages = append(messages, models.ChatMessage{Role: "user", Content: turn})
+		
+		turnRequest := request
+		turnRequest.Messages = append([]models.ChatMessage{}, messages...)
+		
+		result := sendRequest(ctx, service, turnRequest)
+		result.Turn = i + 1
+		results = append(results, result)
+		
+		messages = append(messages, models.ChatMessage{Role: "assistant", Content: result.Response})
+	}
+	
+	return results
+}
+
+// newNonce returns a random token to make a prompt unique
+func newNonce() string {
+	return fmt.Sprintf("[%016x]", rand.Uint64())
+}
+
+// withNonce returns a copy of the messages with the nonce appended to the
+// last user message, keeping the prompt's length roughly the same
+func withNonce(messages []models.ChatMessage, nonce string) []models.ChatMessage {
+	messages = slices.Clone(messages)
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" {
+			messages[i].Content += " " + nonce
+			break
+		}
+	}
+	return messages
+}
+
+// GenerateSummary creates a summary of a run's results, leaving the
+// results themselves untouched
+func (bs *BenchmarkService) GenerateSummary(run *Run) map[string]models.BenchmarkSummary {
 	summaries := make(map[string]models.BenchmarkSummary)
+	results := run.Results
 	
 	for providerName, providerResults := range results {
 		summary := models.BenchmarkSummary{
@@ -138,31 +887,111 @@ func (bs *BenchmarkService) GenerateSummary(results map[string][]models.Benchmar
 			TotalRequests: len(providerResults),
 		}
 		
+		summary.PlannedRequests = run.Planned[providerName]
+		summary.SkippedRequests = max(0, summary.PlannedRequests-summary.TotalRequests)
+		summary.NotStarted = run.NotStarted[providerName]
+		summary.FailFastSkipped = run.FailFastSkipped[providerName]
+		
+		// Carry provider and model names over from the results so that
+		// multi-model runs can be told apart in reports
+		if len(providerResults) > 0 {
+			summary.Provider = providerResults[0].Provider
+			summary.ModelName = providerResults[0].ModelName
+		}
+		
 		var totalResponseTime time.Duration
 		var totalTokens int
 		var minTime, maxTime time.Duration
 		var successCount int
+		responseTimes := make([]time.Duration, 0, len(providerResults))
 		
 		// Streaming metrics
 		var isStreaming bool
 		var totalTTFT time.Duration
 		var minTTFT, maxTTFT time.Duration
+		var ttfts []time.Duration
 		var totalThroughput float64
 		var minThroughput, maxThroughput float64
 		var streamingCount int
+		var throughputs []float64
+		var totalStreamingTokens int
+		var totalStreamingDuration time.Duration
+		var emptyResponses int
+		var correctCount int
+		var totalOutputTokens, outputCount int
+		var totalInputTokens int
+		var totalBytes int
+		var totalSuccessTime time.Duration
+		var network networkAverages
+		isOutlier := outliers(providerResults)
 		
 		for i, result := range providerResults {
+			if result.Success {
+				// Every path fills in the input/output split, so totals are
+				// counted the same way whether the request streamed or not
+				totalTokens += result.InputTokens + result.OutputTokens
+				totalBytes += result.ResponseBytes
+				totalSuccessTime += result.ResponseTime
+				totalOutputTokens += result.OutputTokens
+				totalInputTokens += result.InputTokens
+				summary.CachedInputTokens += result.CachedInputTokens
+				summary.ReasoningTokens += result.ReasoningTokens
+				if result.FinishReason == models.FinishReasonLength {
+					summary.TruncatedResponses++
+				}
+				if result.Logprobs {
+					summary.LogprobsResponses++
+				}
+				if result.TokensEstimated {
+					summary.EstimatedTokenCounts++
+				}
+				network.add(result)
+				outputCount++
+				if outputCount == 1 || result.OutputTokens < summary.MinOutputTokens {
+					summary.MinOutputTokens = result.OutputTokens
+				}
+				if result.OutputTokens > summary.MaxOutputTokens {
+					summary.MaxOutputTokens = result.OutputTokens
+				}
+			}
+			
+			if result.Graded {
+				summary.GradedRequests++
+				if result.Correct {
+					correctCount++
+				}
+			}
+			
+			if !result.Success {
+				// Results saved before errors were classified have no kind
+				kind := result.ErrorKind
+				if kind == "" {
+					kind = models.ErrorKindOther
+				}
+				if summary.ErrorKinds == nil {
+					summary.ErrorKinds = make(map[string]int)
+				}
+				summary.ErrorKinds[kind]++
+			}
+			
 			if result.Success {
 				successCount++
+				if isOutlier[i] {
+					summary.Outliers++
+				}
 				
-				// Count tokens from both streaming and non-streaming
-				if result.IsStreaming {
-					totalTokens += result.StreamingTokens
+				if result.IsStreaming && result.EmptyResponse {
+					// Empty streams have no first token or throughput to
+					// measure, so keep them out of the streaming aggregates
+					isStreaming = true
+					emptyResponses++
+				} else if result.IsStreaming {
 					isStreaming = true
 					
 					// Track streaming metrics
 					if result.TimeToFirstToken > 0 {
 						totalTTFT += result.TimeToFirstToken
+						ttfts = append(ttfts, result.TimeToFirstToken)
 						streamingCount++
 						
 						if streamingCount == 1 || result.TimeToFirstToken < minTTFT {
@@ -176,6 +1005,9 @@ func (bs *BenchmarkService) GenerateSummary(results map[string][]models.Benchmar
 					// Track throughput metrics
 					if result.TokenThroughput > 0 {
 						totalThroughput += result.TokenThroughput
+						throughputs = append(throughputs, result.TokenThroughput)
+						totalStreamingTokens += result.StreamingTokens
+						totalStreamingDuration += result.StreamingDuration
 						
 						if streamingCount == 1 || result.TokenThroughput < minThroughput {
 							minThroughput = result.TokenThroughput
@@ -184,12 +1016,11 @@ func (bs *BenchmarkService) GenerateSummary(results map[string][]models.Benchmar
 							maxThroughput = result.TokenThroughput
 						}
 					}
-				} else {
-					totalTokens += result.TokensUsed
 				}
 			}
 			
 			totalResponseTime += result.ResponseTime
+			responseTimes = append(responseTimes, result.ResponseTime)
 			
 			if i == 0 || result.ResponseTime < minTime {
 				minTime = result.ResponseTime
@@ -207,32 +1038,205 @@ func (bs *BenchmarkService) GenerateSummary(results map[string][]models.Benchmar
 			summary.AvgResponseTime = totalResponseTime / time.Duration(summary.TotalRequests)
 			summary.ErrorRate = float64(summary.FailedRequests) / float64(summary.TotalRequests) * 100
 		}
+		if summary.CachedInputTokens > 0 && totalInputTokens > 0 {
+			summary.CacheHitRate = float64(summary.CachedInputTokens) / float64(totalInputTokens) * 100
+		}
+		if outputCount > 0 {
+			summary.AvgOutputTokens = float64(totalOutputTokens) / float64(outputCount)
+			summary.AvgResponseBytes = float64(totalBytes) / float64(outputCount)
+			summary.AvgReasoningTokens = float64(summary.ReasoningTokens) / float64(outputCount)
+			summary.TruncatedRate = float64(summary.TruncatedResponses) / float64(outputCount) * 100
+		}
+		if successCount > 0 {
+			summary.AvgSuccessResponseTime = totalSuccessTime / time.Duration(successCount)
+		}
+		if totalSuccessTime > 0 {
+			summary.ByteThroughput = float64(totalBytes) / totalSuccessTime.Seconds()
+		}
+		network.apply(&summary)
+		if summary.GradedRequests > 0 {
+			summary.Accuracy = float64(correctCount) / float64(summary.GradedRequests) * 100
+		}
 		
 		summary.MinResponseTime = minTime
 		summary.MaxResponseTime = maxTime
 		
+		sort.Slice(responseTimes, func(i, j int) bool { return responseTimes[i] < responseTimes[j] })
+		summary.P50ResponseTime = percentile(responseTimes, 50)
+		summary.P95ResponseTime = percentile(responseTimes, 95)
+		summary.P99ResponseTime = percentile(responseTimes, 99)
+		summary.TurnStats = turnStats(providerResults)
+		summary.KeyStats = keyStats(providerResults)
+		summary.ColdStartLatency = coldStartLatency(providerResults)
+		if bs.config.ArrivalRate > 0 {
+			summary.InterArrival = interArrivalStats(providerResults, bs.config.ArrivalRate)
+		}
+		if run.TraceEntries > 0 {
+			summary.Trace = traceStats(providerResults, run.TraceEntries)
+		}
+		
 		// Set streaming metrics if applicable
 		if isStreaming {
 			summary.IsStreaming = true
+			summary.EmptyResponses = emptyResponses
 			
 			if streamingCount > 0 {
 				summary.AvgTimeToFirstToken = totalTTFT / time.Duration(streamingCount)
 				summary.MinTimeToFirstToken = minTTFT
 				summary.MaxTimeToFirstToken = maxTTFT
 				
+				sort.Slice(ttfts, func(i, j int) bool { return ttfts[i] < ttfts[j] })
+				summary.P50TimeToFirstToken = percentile(ttfts, 50)
+				summary.P90TimeToFirstToken = percentile(ttfts, 90)
+				summary.P99TimeToFirstToken = percentile(ttfts, 99)
+				
 				summary.AvgTokenThroughput = totalThroughput / float64(streamingCount)
 				summary.MinTokenThroughput = minThroughput
 				summary.MaxTokenThroughput = maxThroughput
+				
+				sort.Float64s(throughputs)
+				summary.MedianTokenThroughput = percentileFloat(throughputs, 50)
+				summary.P90TokenThroughput = percentileFloat(throughputs, 90)
+			}
+			
+			// Overall throughput weighs every token equally instead of
+			// averaging per-request rates
+			if totalStreamingDuration > 0 {
+				summary.OverallTokenThroughput = float64(totalStreamingTokens) / totalStreamingDuration.Seconds()
 			}
 		}
 		
 		summaries[providerName] = summary
 	}
 	
+	if bs.config.Scoring != nil {
+		ScoreSummaries(summaries, *bs.config.Scoring)
+	}
+
 	return summaries
 }
 
+// turnStats breaks successful response times down by conversation turn depth
+func turnStats(results []models.BenchmarkResult) []models.TurnStats {
+	byTurn := make(map[int][]time.Duration)
+	for _, result := range results {
+		if result.Turn > 0 && result.Success {
+			byTurn[result.Turn] = append(byTurn[result.Turn], result.ResponseTime)
+		}
+	}
+	
+	if len(byTurn) == 0 {
+		return nil
+	}
+	
+	turns := make([]int, 0, len(byTurn))
+	for turn := range byTurn {
+		turns = append(turns, turn)
+	}
+	sort.Ints(turns)
+	
+	stats := make([]models.TurnStats, 0, len(turns))
+	for _, turn := range turns {
+		times := byTurn[turn]
+		sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+		
+		var total time.Duration
+		for _, t := range times {
+			total += t
+		}
+		
+		stats = append(stats, models.TurnStats{
+			Turn:            turn,
+			Requests:        len(times),
+			AvgResponseTime: total / time.Duration(len(times)),
+			P95ResponseTime: percentile(times, 95),
+		})
+	}
+	
+	return stats
+}
+
+// coldStartLatency returns the latency of the first successful request to
+// complete, in completion order with ties broken by request index
+func coldStartLatency(results []models.BenchmarkResult) time.Duration {
+	var first *models.BenchmarkResult
+	for i := range results {
+		result := &results[i]
+		if !result.Success {
+			continue
+		}
+		if first == nil || result.CompletedAt().Before(first.CompletedAt()) ||
+			(result.CompletedAt().Equal(first.CompletedAt()) && result.Index < first.Index) {
+			first = result
+		}
+	}
+	
+	if first == nil {
+		return 0
+	}
+	return first.ResponseTime
+}
+
+// keyStats breaks requests and errors down by the API key they were sent
+// with, when more than one key was used
+func keyStats(results []models.BenchmarkResult) []models.KeyStats {
+	byKey := make(map[int]*models.KeyStats)
+	for _, result := range results {
+		stats, ok := byKey[result.KeyIndex]
+		if !ok {
+			stats = &models.KeyStats{Key: result.KeyIndex}
+			byKey[result.KeyIndex] = stats
+		}
+		stats.Requests++
+		if !result.Success {
+			stats.Failed++
+		}
+	}
+	
+	if len(byKey) < 2 {
+		return nil
+	}
+	
+	stats := make([]models.KeyStats, 0, len(byKey))
+	for _, key := range slices.Sorted(maps.Keys(byKey)) {
+		entry := *byKey[key]
+		entry.ErrorRate = float64(entry.Failed) / float64(entry.Requests) * 100
+		stats = append(stats, entry)
+	}
+	
+	return stats
+}
+
+// percentile returns the nearest-rank percentile p of the sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// percentileFloat returns the nearest-rank percentile p of the sorted values
+func percentileFloat(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
 // GetProviders returns the configured providers
 func (bs *BenchmarkService) GetProviders() []models.Provider {
 	return bs.providers
 }
+
+// GetConfig returns the effective benchmark configuration
+func (bs *BenchmarkService) GetConfig() models.BenchmarkConfig {
+	return bs.config
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"llmbench/internal/models"
+)
+
+func TestMaxDurationStartsWithProviderSlot(t *testing.T) {
+	// Each provider takes longer than the budget to finish its requests,
+	// so the second one would be left none if it shared the first's clock
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(150 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"1","object":"chat.completion","created":0,"model":"m","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}],"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`)
+	}))
+	defer server.Close()
+
+	config := models.BenchmarkConfig{
+		Providers: []models.Provider{
+			{Name: "first", BaseURL: server.URL, APIKey: "key", Models: []string{"m"}},
+			{Name: "second", BaseURL: server.URL, APIKey: "key", Models: []string{"m"}},
+		},
+		Requests:             2,
+		Concurrency:          1,
+		Timeout:              "5s",
+		MaxDuration:          "250ms",
+		MaxParallelProviders: 1,
+	}
+	bs, err := NewBenchmarkService(config)
+	if err != nil {
+		t.Fatalf("NewBenchmarkService: %v", err)
+	}
+	defer bs.Close()
+
+	run, err := bs.RunBenchmark(context.Background(), models.BenchmarkRequest{
+		Messages: []models.ChatMessage{{Role: "user", Content: "Hello"}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("RunBenchmark: %v", err)
+	}
+
+	for _, key := range []string{"first/m", "second/m"} {
+		if got := len(run.Results[key]); got != 2 {
+			t.Errorf("%s: %d results, want 2", key, got)
+		}
+		if run.NotStarted[key] != 0 {
+			t.Errorf("%s: %d requests not started, want 0", key, run.NotStarted[key])
+		}
+	}
+}
+
+func TestIdleWorkers(t *testing.T) {
+	config := models.BenchmarkConfig{
+		Providers: []models.Provider{
+			{Name: "global", Models: []string{"m"}},
+			{Name: "override", Models: []string{"m"}, Concurrency: 8},
+			{Name: "lowered", Models: []string{"m"}, Concurrency: 2},
+		},
+		Requests:    4,
+		Concurrency: 6,
+		Timeout:     "5s",
+	}
+	request := models.BenchmarkRequest{Messages: []models.ChatMessage{{Role: "user", Content: "Hello"}}}
+
+	bs, err := NewBenchmarkService(config)
+	if err != nil {
+		t.Fatalf("NewBenchmarkService: %v", err)
+	}
+	want := map[string]int{"global": 6, "override": 8}
+	if got := bs.IdleWorkers(request); !maps.Equal(got, want) {
+		t.Errorf("IdleWorkers() = %v, want %v", got, want)
+	}
+
+	// Scheduled requests don't go through workers
+	config.ArrivalRate = 10
+	if bs, err = NewBenchmarkService(config); err != nil {
+		t.Fatalf("NewBenchmarkService: %v", err)
+	}
+	if got := bs.IdleWorkers(request); len(got) != 0 {
+		t.Errorf("IdleWorkers() with an arrival rate = %v, want none", got)
+	}
+}
+
+func TestKeySchedule(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider models.Provider
+		clients  int
+		want     []int
+	}{
+		{"single key", models.Provider{APIKey: "a"}, 1, []int{0}},
+		{"round-robin", models.Provider{APIKey: "a", APIKeys: []string{"b", "c"}}, 3, []int{0, 1, 2}},
+		{"weighted", models.Provider{APIKey: "a", APIKeys: []string{"b"}, KeyWeights: []int{2, 1}}, 2, []int{0, 1, 0}},
+		{"interleaved", models.Provider{APIKey: "a", APIKeys: []string{"b", "c"}, KeyWeights: []int{3, 1, 1}}, 3, []int{0, 1, 0, 2, 0}},
+	}
+
+	for _, test := range tests {
+		if got := keySchedule(test.provider, test.clients); !slices.Equal(got, test.want) {
+			t.Errorf("%s: keySchedule() = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
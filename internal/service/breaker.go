@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"llmbench/internal/models"
+)
+
+// errProvidersUnreachable aborts a run whose requests keep timing out
+var errProvidersUnreachable = errors.New("providers unreachable")
+
+// timeoutBreaker aborts a run once a number of requests in a row, across
+// every provider, timed out: a network outage would otherwise take the
+// full timeout for every request. Any other outcome closes it again. A nil
+// timeoutBreaker never trips.
+type timeoutBreaker struct {
+	threshold int
+	cancel    context.CancelCauseFunc
+
+	mu          sync.Mutex
+	consecutive int
+}
+
+// newTimeoutBreaker creates a breaker cancelling the run through cancel
+// after threshold consecutive timeouts, or returns nil when threshold is 0
+func newTimeoutBreaker(threshold int, cancel context.CancelCauseFunc) *timeoutBreaker {
+	if threshold <= 0 {
+		return nil
+	}
+	return &timeoutBreaker{threshold: threshold, cancel: cancel}
+}
+
+// record counts a request's outcome, tripping the breaker on the
+// threshold-th timeout in a row
+func (b *timeoutBreaker) record(result models.BenchmarkResult) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if result.ErrorKind != models.ErrorKindTimeout {
+		b.consecutive = 0
+		return
+	}
+	b.consecutive++
+	if b.consecutive == b.threshold {
+		b.cancel(fmt.Errorf("%w: %d requests in a row timed out, aborting the run", errProvidersUnreachable, b.threshold))
+	}
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"llmbench/internal/models"
+)
+
+// MetricInterval is a metric's mean over repeated runs, with the half-width
+// of its 95% confidence interval
+type MetricInterval struct {
+	Mean      float64 `json:"mean"`
+	HalfWidth float64 `json:"ci95"`
+	Runs      int     `json:"runs"` // runs that measured the metric
+}
+
+// Overlaps reports whether two intervals overlap, in which case the
+// difference between their means isn't significant
+func (m MetricInterval) Overlaps(other MetricInterval) bool {
+	return m.Mean-m.HalfWidth <= other.Mean+other.HalfWidth &&
+		other.Mean-other.HalfWidth <= m.Mean+m.HalfWidth
+}
+
+// RepeatedMetric holds one metric's intervals for every provider/model
+type RepeatedMetric struct {
+	Name      string                    `json:"name"`
+	Unit      string                    `json:"unit,omitempty"`
+	Duration  bool                      `json:"duration,omitempty"` // values are nanoseconds
+	Intervals map[string]MetricInterval `json:"intervals"`
+}
+
+// Overlapping lists the pairs of provider/models whose intervals overlap
+func (m RepeatedMetric) Overlapping() [][2]string {
+	keys := make([]string, 0, len(m.Intervals))
+	for key := range m.Intervals {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var pairs [][2]string
+	for i, a := range keys {
+		for _, b := range keys[i+1:] {
+			if m.Intervals[a].Overlaps(m.Intervals[b]) {
+				pairs = append(pairs, [2]string{a, b})
+			}
+		}
+	}
+	return pairs
+}
+
+// repeatedMetrics are the summary metrics aggregated over repeated runs;
+// value reports false when a summary doesn't measure the metric
+var repeatedMetrics = []struct {
+	name     string
+	unit     string
+	duration bool
+	value    func(models.BenchmarkSummary) (float64, bool)
+}{
+	{"Avg Response Time", "", true, durationMetric(func(s models.BenchmarkSummary) time.Duration { return s.AvgResponseTime })},
+	{"P50 Response Time", "", true, durationMetric(func(s models.BenchmarkSummary) time.Duration { return s.P50ResponseTime })},
+	{"P95 Response Time", "", true, durationMetric(func(s models.BenchmarkSummary) time.Duration { return s.P95ResponseTime })},
+	{"P99 Response Time", "", true, durationMetric(func(s models.BenchmarkSummary) time.Duration { return s.P99ResponseTime })},
+	{"Avg Time to First Token", "", true, durationMetric(func(s models.BenchmarkSummary) time.Duration { return s.AvgTimeToFirstToken })},
+	{"Avg Token Throughput", "tokens/sec", false, func(s models.BenchmarkSummary) (float64, bool) {
+		return s.AvgTokenThroughput, s.AvgTokenThroughput > 0
+	}},
+	{"Error Rate", "%", false, func(s models.BenchmarkSummary) (float64, bool) {
+		return s.ErrorRate, s.TotalRequests > 0
+	}},
+}
+
+// durationMetric measures a duration, which is unset when zero
+func durationMetric(get func(models.BenchmarkSummary) time.Duration) func(models.BenchmarkSummary) (float64, bool) {
+	return func(s models.BenchmarkSummary) (float64, bool) {
+		d := get(s)
+		return float64(d), d > 0
+	}
+}
+
+// AggregateRuns computes the mean and 95% confidence interval of every
+// metric of every provider/model over the summaries of repeated runs
+func AggregateRuns(runs []map[string]models.BenchmarkSummary) []RepeatedMetric {
+	var metrics []RepeatedMetric
+	for _, metric := range repeatedMetrics {
+		values := make(map[string][]float64)
+		for _, summaries := range runs {
+			for key, summary := range summaries {
+				if value, ok := metric.value(summary); ok {
+					values[key] = append(values[key], value)
+				}
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+
+		intervals := make(map[string]MetricInterval, len(values))
+		for key, samples := range values {
+			intervals[key] = confidenceInterval(samples)
+		}
+		metrics = append(metrics, RepeatedMetric{
+			Name:      metric.name,
+			Unit:      metric.unit,
+			Duration:  metric.duration,
+			Intervals: intervals,
+		})
+	}
+	return metrics
+}
+
+// confidenceInterval returns the mean of the samples and the half-width of
+// its 95% confidence interval, using Student's t distribution as there are
+// usually few runs
+func confidenceInterval(samples []float64) MetricInterval {
+	n := len(samples)
+	mean := 0.0
+	for _, sample := range samples {
+		mean += sample
+	}
+	mean /= float64(n)
+
+	if n < 2 {
+		return MetricInterval{Mean: mean, Runs: n}
+	}
+
+	variance := 0.0
+	for _, sample := range samples {
+		variance += (sample - mean) * (sample - mean)
+	}
+	stdDev := math.Sqrt(variance / float64(n-1))
+
+	return MetricInterval{
+		Mean:      mean,
+		HalfWidth: tCritical95(n-1) * stdDev / math.Sqrt(float64(n)),
+		Runs:      n,
+	}
+}
+
+// tCritical95 returns the two-sided 95% critical value of Student's t
+// distribution with the given degrees of freedom
+func tCritical95(df int) float64 {
+	table := []float64{
+		12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+		2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+		2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+	}
+	if df >= 1 && df <= len(table) {
+		return table[df-1]
+	}
+	return 1.96
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/openai/openai-go/option"
+)
+
+// debugTraceEntry is one HTTP exchange with a provider, as written to the
+// debug trace file
+type debugTraceEntry struct {
+	Time         time.Time `json:"time"`
+	Provider     string    `json:"provider"`
+	Method       string    `json:"method"`
+	URL          string    `json:"url"`
+	RequestBody  string    `json:"request_body,omitempty"`
+	Status       int       `json:"status,omitempty"`
+	ResponseBody string    `json:"response_body,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	DurationMs   float64   `json:"duration_ms"` // from sending the request to the end of its response body
+}
+
+// debugTrace appends every HTTP exchange with providers, bodies included,
+// to a JSONL file; nil when disabled
+var debugTrace *debugTraceFile
+
+// debugTraceFile serializes entries written by concurrent requests
+type debugTraceFile struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// SetDebugTrace starts appending every request and response body to the
+// given file, one JSON object per line. It records prompts and responses
+// verbatim, so it is meant for occasional debugging only
+func SetDebugTrace(filename string) error {
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open debug trace: %w", err)
+	}
+	debugTrace = &debugTraceFile{file: file}
+	return nil
+}
+
+// CloseDebugTrace stops recording the debug trace and closes its file
+func CloseDebugTrace() error {
+	if debugTrace == nil {
+		return nil
+	}
+	err := debugTrace.file.Close()
+	debugTrace = nil
+	return err
+}
+
+// write appends an entry to the trace file
+func (t *debugTraceFile) write(entry debugTraceEntry) {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, err := t.file.Write(append(line, '\n')); err != nil {
+		logf("failed to write debug trace: %v", err)
+	}
+}
+
+// debugTraceMiddleware records the request body, then the status and body
+// of the response as the client reads it, so streams are recorded without
+// being buffered ahead of the client
+func debugTraceMiddleware(trace *debugTraceFile, providerName string) option.Middleware {
+	return func(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+		entry := debugTraceEntry{
+			Time:     time.Now(),
+			Provider: providerName,
+			Method:   req.Method,
+			URL:      req.URL.String(),
+		}
+		if req.Body != nil {
+			body, err := io.ReadAll(req.Body)
+			req.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+			entry.RequestBody = string(body)
+			req.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		res, err := next(req)
+		if err != nil {
+			entry.Error = err.Error()
+			entry.DurationMs = float64(time.Since(entry.Time)) / float64(time.Millisecond)
+			trace.write(entry)
+			return res, err
+		}
+
+		entry.Status = res.StatusCode
+		res.Body = &debugTraceBody{ReadCloser: res.Body, trace: trace, entry: entry}
+		return res, nil
+	}
+}
+
+// debugTraceBody copies a response body as it is read, and writes its trace
+// entry when it is closed
+type debugTraceBody struct {
+	io.ReadCloser
+	trace  *debugTraceFile
+	entry  debugTraceEntry
+	body   bytes.Buffer
+	closed bool
+}
+
+func (b *debugTraceBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.body.Write(p[:n])
+	if err != nil && err != io.EOF {
+		b.entry.Error = err.Error()
+	}
+	return n, err
+}
+
+func (b *debugTraceBody) Close() error {
+	err := b.ReadCloser.Close()
+	if !b.closed {
+		b.closed = true
+		b.entry.ResponseBody = b.body.String()
+		b.entry.DurationMs = float64(time.Since(b.entry.Time)) / float64(time.Millisecond)
+		b.trace.write(b.entry)
+	}
+	return err
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// etaWindow is how many recent progress updates the completion rate is
+	// computed over, so the estimate follows changes in provider speed
+	etaWindow = 20
+
+	// etaMinSamples is how many progress updates are needed before the
+	// estimate is considered meaningful
+	etaMinSamples = 3
+)
+
+// etaSample is the progress of a provider/model at a point in time
+type etaSample struct {
+	at        time.Time
+	completed int
+}
+
+// ETATracker estimates the remaining time of every provider/model from
+// its rolling completion rate; it is safe for concurrent use
+type ETATracker struct {
+	mu      sync.Mutex
+	samples map[string][]etaSample
+}
+
+// NewETATracker creates an empty ETA tracker
+func NewETATracker() *ETATracker {
+	return &ETATracker{samples: make(map[string][]etaSample)}
+}
+
+// Record notes that a provider/model has completed the given number of
+// requests
+func (t *ETATracker) Record(key string, completed int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	samples := append(t.samples[key], etaSample{at: time.Now(), completed: completed})
+	if len(samples) > etaWindow {
+		samples = samples[len(samples)-etaWindow:]
+	}
+	t.samples[key] = samples
+}
+
+// Remaining estimates how long a provider/model needs to complete total
+// requests; ok is false until enough progress was recorded
+func (t *ETATracker) Remaining(key string, total int) (remaining time.Duration, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	samples := t.samples[key]
+	if len(samples) < etaMinSamples {
+		return 0, false
+	}
+
+	first, last := samples[0], samples[len(samples)-1]
+	elapsed := last.at.Sub(first.at)
+	done := last.completed - first.completed
+	if elapsed <= 0 || done <= 0 {
+		return 0, false
+	}
+
+	left := total - last.completed
+	if left <= 0 {
+		return 0, true
+	}
+
+	// Time already spent since the last update counts against the estimate
+	remaining = time.Duration(float64(elapsed)/float64(done)*float64(left)) - time.Since(last.at)
+	return max(remaining, 0), true
+}
+
+// Describe returns a short description of a provider/model's ETA, such
+// as "ETA 1m5s", or "estimating…" while there is too little progress
+func (t *ETATracker) Describe(key string, total int) string {
+	remaining, ok := t.Remaining(key, total)
+	if !ok {
+		return "estimating…"
+	}
+	return fmt.Sprintf("ETA %v", remaining.Round(time.Second))
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"time"
+
+	"llmbench/internal/models"
+)
+
+// RunTotals aggregates every provider/model of a run
+type RunTotals struct {
+	Requests     int
+	Successful   int
+	OutputTokens int           // output tokens of successful requests
+	WallClock    time.Duration // first request start to last request completion
+	Goodput      float64       // successful output tokens per second of wall-clock time
+}
+
+// Totals aggregates the results of every provider/model. Goodput divides
+// the output tokens successfully delivered by the run's wall-clock time, so
+// unlike averaged per-request throughputs it reflects what the system
+// delivered as a whole, concurrency, failures and idle time included
+func Totals(results map[string][]models.BenchmarkResult) RunTotals {
+	var totals RunTotals
+	var first, last time.Time
+	for _, providerResults := range results {
+		for _, result := range providerResults {
+			totals.Requests++
+			if result.Success {
+				totals.Successful++
+				totals.OutputTokens += result.OutputTokens
+			}
+
+			// Results saved before start times were recorded have no timing
+			if result.StartedAt.IsZero() {
+				continue
+			}
+			if first.IsZero() || result.StartedAt.Before(first) {
+				first = result.StartedAt
+			}
+			if completed := result.CompletedAt(); completed.After(last) {
+				last = completed
+			}
+		}
+	}
+
+	if !first.IsZero() {
+		totals.WallClock = last.Sub(first)
+	}
+	if totals.WallClock > 0 {
+		totals.Goodput = float64(totals.OutputTokens) / totals.WallClock.Seconds()
+	}
+	return totals
+}
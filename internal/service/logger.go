@@ -0,0 +1,46 @@
+package service
+
+import (
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/openai/openai-go/option"
+)
+
+// logger writes verbose diagnostics to stderr so they never mix with
+// results written to stdout
+var (
+	logger  = log.New(os.Stderr, "[llmbench] ", log.LstdFlags|log.Lmicroseconds)
+	verbose bool
+)
+
+// SetVerbose enables or disables verbose request logging
+func SetVerbose(enabled bool) {
+	verbose = enabled
+}
+
+// logf logs a formatted message when verbose mode is enabled
+func logf(format string, args ...any) {
+	if verbose {
+		logger.Printf(format, args...)
+	}
+}
+
+// loggingMiddleware logs every HTTP attempt made by the OpenAI client,
+// including the retries it performs on its own
+func loggingMiddleware(providerName string) option.Middleware {
+	return func(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+		if retry := req.Header.Get("X-Stainless-Retry-Count"); retry != "" && retry != "0" {
+			logf("%s: retry attempt %s for %s %s", providerName, retry, req.Method, req.URL.Path)
+		}
+
+		res, err := next(req)
+		if err != nil {
+			logf("%s: %s %s failed: %v", providerName, req.Method, req.URL.Path, err)
+		} else {
+			logf("%s: %s %s -> %s", providerName, req.Method, req.URL.Path, res.Status)
+		}
+		return res, err
+	}
+}
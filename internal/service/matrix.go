@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"llmbench/internal/models"
+)
+
+// RunMatrix benchmarks every combination of the configured matrix values,
+// one combination at a time; onCell, when set, is called before each one
+// is run with its position in the matrix
+func (bs *BenchmarkService) RunMatrix(ctx context.Context, request models.BenchmarkRequest, onCell func(cell models.MatrixCell, index, total int)) (*models.MatrixResults, error) {
+	matrix := &models.MatrixResults{
+		Concurrency: []int{bs.config.Concurrency},
+		MaxTokens:   []int{request.MaxTokens},
+	}
+	if bs.config.Matrix != nil {
+		if len(bs.config.Matrix.Concurrency) > 0 {
+			matrix.Concurrency = bs.config.Matrix.Concurrency
+		}
+		if len(bs.config.Matrix.MaxTokens) > 0 {
+			matrix.MaxTokens = bs.config.Matrix.MaxTokens
+		}
+	}
+
+	total := len(matrix.Concurrency) * len(matrix.MaxTokens)
+	for _, concurrency := range matrix.Concurrency {
+		for _, maxTokens := range matrix.MaxTokens {
+			cell := models.MatrixCell{Concurrency: concurrency, MaxTokens: maxTokens}
+			if onCell != nil {
+				onCell(cell, len(matrix.Cells), total)
+			}
+
+			cellRequest := request
+			cellRequest.MaxTokens = maxTokens
+
+			run, err := bs.runBenchmark(ctx, bs.providers, cellRequest, concurrency, nil)
+			if err != nil {
+				return nil, fmt.Errorf("concurrency %d, max tokens %d: %w", concurrency, maxTokens, err)
+			}
+
+			cell.Results = run.Results
+			cell.Summaries = bs.GenerateSummary(run)
+			matrix.Cells = append(matrix.Cells, cell)
+		}
+	}
+
+	return matrix, nil
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"llmbench/internal/models"
+)
+
+// networkTimer breaks a request's time down into its network phases with
+// httptrace. When the client retries, the last attempt's phases are kept
+type networkTimer struct {
+	start time.Time
+
+	// Dialing runs on its own goroutine, so phases are guarded
+	mu           sync.Mutex
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	dns          time.Duration
+	connect      time.Duration
+	tls          time.Duration
+	ttfb         time.Duration
+}
+
+// newNetworkTimer creates a timer for a request started at start
+func newNetworkTimer(start time.Time) *networkTimer {
+	return &networkTimer{start: start}
+}
+
+// trace returns a context that reports the request's network phases to
+// the timer
+func (t *networkTimer) trace(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart:          func(httptrace.DNSStartInfo) { t.begin(&t.dnsStart) },
+		DNSDone:           func(httptrace.DNSDoneInfo) { t.end(&t.dns, &t.dnsStart) },
+		ConnectStart:      func(string, string) { t.begin(&t.connectStart) },
+		ConnectDone:       func(string, string, error) { t.end(&t.connect, &t.connectStart) },
+		TLSHandshakeStart: func() { t.begin(&t.tlsStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { t.end(&t.tls, &t.tlsStart) },
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			t.ttfb = time.Since(t.start)
+			t.mu.Unlock()
+		},
+	})
+}
+
+// begin marks the start of a phase
+func (t *networkTimer) begin(start *time.Time) {
+	t.mu.Lock()
+	*start = time.Now()
+	t.mu.Unlock()
+}
+
+// end records how long a phase took since it began
+func (t *networkTimer) end(phase *time.Duration, start *time.Time) {
+	t.mu.Lock()
+	if !start.IsZero() {
+		*phase = time.Since(*start)
+	}
+	t.mu.Unlock()
+}
+
+// record copies the phases measured so far onto a result
+func (t *networkTimer) record(result *models.BenchmarkResult) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result.DNSTime = t.dns
+	result.ConnectTime = t.connect
+	result.TLSTime = t.tls
+	result.TTFB = t.ttfb
+}
+
+// networkAverages sums the network phases of successful results, each over
+// the results where the phase happened
+type networkAverages struct {
+	dns, connect, tls, ttfb                     time.Duration
+	dnsCount, connectCount, tlsCount, ttfbCount int
+}
+
+// add counts the phases of a result
+func (a *networkAverages) add(result models.BenchmarkResult) {
+	if result.DNSTime > 0 {
+		a.dns += result.DNSTime
+		a.dnsCount++
+	}
+	if result.ConnectTime > 0 {
+		a.connect += result.ConnectTime
+		a.connectCount++
+	}
+	if result.TLSTime > 0 {
+		a.tls += result.TLSTime
+		a.tlsCount++
+	}
+	if result.TTFB > 0 {
+		a.ttfb += result.TTFB
+		a.ttfbCount++
+	}
+}
+
+// apply sets the average phases on a summary
+func (a *networkAverages) apply(summary *models.BenchmarkSummary) {
+	average := func(total time.Duration, count int) time.Duration {
+		if count == 0 {
+			return 0
+		}
+		return total / time.Duration(count)
+	}
+	summary.AvgDNSTime = average(a.dns, a.dnsCount)
+	summary.AvgConnectTime = average(a.connect, a.connectCount)
+	summary.AvgTLSTime = average(a.tls, a.tlsCount)
+	summary.AvgTTFB = average(a.ttfb, a.ttfbCount)
+}
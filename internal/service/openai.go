@@ -1,8 +1,19 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"syscall"
 	"time"
 
 	"llmbench/internal/models"
@@ -17,18 +28,79 @@ type OpenAIService struct {
 	client       openai.Client
 	provider     models.Provider
 	timeout      time.Duration
+	stallTimeout time.Duration // 0 leaves streams to timeout alone
+	strict       bool          // fail 2xx responses without content or with an error body
 	tokenCounter *utils.TokenCounter
+	httpClient   *http.Client // nil when the default client is used
+	tokens       *tokenSource // nil with static API keys
 }
 
+// errStreamStall cancels a stream that went quiet for the stall timeout
+var errStreamStall = errors.New("stream stalled")
+
+// errInvalidResponse fails a 2xx response that strict checking rejects
+var errInvalidResponse = errors.New("invalid response")
+
 // NewOpenAIService creates a new OpenAI service instance
 func NewOpenAIService(provider models.Provider, timeout time.Duration) *OpenAIService {
-	opts := []option.RequestOption{
-		option.WithAPIKey(provider.APIKey),
+	return newOpenAIService(provider, timeout, newTokenSource(provider))
+}
+
+// newOpenAIService creates a new OpenAI service instance authenticating
+// with the given token source, nil for static API keys
+func newOpenAIService(provider models.Provider, timeout time.Duration, tokens *tokenSource) *OpenAIService {
+	var opts []option.RequestOption
+
+	// A provider may set api_keys alone
+	apiKey := provider.APIKey
+	if apiKey == "" && len(provider.APIKeys) > 0 {
+		apiKey = provider.APIKeys[0]
+	}
+
+	if provider.IsAzure() {
+		// Azure routes requests by deployment and authenticates with an
+		// api-key header rather than a bearer token
+		baseURL := fmt.Sprintf("%s/openai/deployments/%s/", strings.TrimRight(provider.BaseURL, "/"), provider.Deployment)
+		opts = append(opts,
+			option.WithBaseURL(baseURL),
+			option.WithQuery("api-version", provider.APIVersion),
+		)
+		if provider.TokenAuth == nil {
+			opts = append(opts,
+				option.WithHeader("api-key", apiKey),
+				option.WithHeaderDel("authorization"),
+			)
+		}
+	} else {
+		opts = append(opts, option.WithAPIKey(apiKey))
+
+		// Set custom base URL if different from OpenAI's default
+		if provider.BaseURL != "" && provider.BaseURL != "https://api.openai.com/v1" {
+			opts = append(opts, option.WithBaseURL(provider.BaseURL))
+		}
+	}
+
+	// Extra headers required by gateways and aggregators
+	for key, value := range provider.Headers {
+		opts = append(opts, option.WithHeader(key, value))
+	}
+
+	httpClient := newHTTPClient(provider)
+	if httpClient != nil {
+		opts = append(opts, option.WithHTTPClient(httpClient))
+	}
+
+	// Short-lived bearer tokens are set per request, refreshed as they expire
+	if tokens != nil {
+		opts = append(opts, option.WithMiddleware(tokens.middleware()))
+	}
+
+	if debugTrace != nil {
+		opts = append(opts, option.WithMiddleware(debugTraceMiddleware(debugTrace, provider.Name)))
 	}
 
-	// Set custom base URL if different from OpenAI's default
-	if provider.BaseURL != "" && provider.BaseURL != "https://api.openai.com/v1" {
-		opts = append(opts, option.WithBaseURL(provider.BaseURL))
+	if verbose {
+		opts = append(opts, option.WithMiddleware(loggingMiddleware(provider.Name)))
 	}
 
 	client := openai.NewClient(opts...)
@@ -45,27 +117,120 @@ func NewOpenAIService(provider models.Provider, timeout time.Duration) *OpenAISe
 		provider:     provider,
 		timeout:      timeout,
 		tokenCounter: tokenCounter,
+		httpClient:   httpClient,
+		tokens:       tokens,
 	}
 }
 
-// SendChatCompletion sends a chat completion request and measures performance
-func (s *OpenAIService) SendChatCompletion(ctx context.Context, request models.BenchmarkRequest) models.BenchmarkResult {
-	start := time.Now()
+// FetchToken fetches the provider's bearer token ahead of the first
+// request, so that fetching it isn't measured; it does nothing for
+// providers with static API keys
+func (s *OpenAIService) FetchToken(ctx context.Context) error {
+	if s.tokens == nil {
+		return nil
+	}
+	_, err := s.tokens.Token(ctx)
+	return err
+}
 
-	result := models.BenchmarkResult{
-		Provider: s.provider.Name,
+// prewarmTimeout bounds a prewarm request, which only has to reach the host
+const prewarmTimeout = 10 * time.Second
+
+// Prewarm resolves the provider's host and opens a connection to it with a
+// HEAD request through the client chat requests use, leaving the connection
+// idle for the first of them. Any response will do; only reaching the host
+// matters, so no tokens are spent
+func (s *OpenAIService) Prewarm(ctx context.Context) error {
+	baseURL := s.provider.BaseURL
+	if baseURL == "" {
+		baseURL = "https://api.openai.com/v1"
 	}
 
-	// Create context with timeout
-	timeoutCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	ctx, cancel := context.WithTimeout(ctx, prewarmTimeout)
 	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, baseURL, nil)
+	if err != nil {
+		return fmt.Errorf("invalid base URL: %w", err)
+	}
+
+	client := s.httpClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	// A drained and closed body returns the connection to the pool
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return nil
+}
 
+// countTokens counts the tokens of a response's content as the given model
+// would. The counter only takes a model for whole conversations, so with a
+// configured tokenizer the content is counted as a lone assistant message,
+// less the tokens that message adds around any content
+func (s *OpenAIService) countTokens(content, countAs string, knownTokenizer bool) int {
+	if !knownTokenizer {
+		return s.tokenCounter.CountTokens(content)
+	}
+	message := []models.ChatMessage{{Role: "assistant", Content: content}}
+	empty := []models.ChatMessage{{Role: "assistant"}}
+	return s.tokenCounter.CountChatCompletionTokens(message, countAs) - s.tokenCounter.CountChatCompletionTokens(empty, countAs)
+}
+
+// SetStallTimeout sets how long a stream may go without a chunk before it is
+// aborted as stalled; 0 disables the check
+func (s *OpenAIService) SetStallTimeout(timeout time.Duration) {
+	s.stallTimeout = timeout
+}
+
+// SetStrictResponses sets whether responses that succeeded at the HTTP layer
+// but hold no content or an error payload count as failures
+func (s *OpenAIService) SetStrictResponses(strict bool) {
+	s.strict = strict
+}
+
+// newHTTPClient builds an HTTP client honouring the provider's proxy, TLS
+// and keep-alive settings, or returns nil when the default client will do
+func newHTTPClient(provider models.Provider) *http.Client {
+	if provider.ProxyURL == "" && !provider.InsecureSkipVerify && !provider.DisableKeepAlives {
+		return nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DisableKeepAlives = provider.DisableKeepAlives
+
+	if provider.ProxyURL != "" {
+		if proxyURL, err := url.Parse(provider.ProxyURL); err == nil {
+			transport.Proxy = http.ProxyURL(proxyURL)
+		}
+	}
+
+	if provider.InsecureSkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	return &http.Client{Transport: transport}
+}
+
+// buildChatRequest converts a benchmark request into OpenAI chat completion params
+func (s *OpenAIService) buildChatRequest(request models.BenchmarkRequest) openai.ChatCompletionNewParams {
 	// Convert our messages to OpenAI format
 	messages := make([]openai.ChatCompletionMessageParamUnion, len(request.Messages))
+	imagesSent := false
 	for i, msg := range request.Messages {
 		switch msg.Role {
 		case "user":
-			messages[i] = openai.UserMessage(msg.Content)
+			// Images go with the first user message only, so a conversation
+			// sends them once rather than on every turn
+			if len(request.Images) > 0 && !imagesSent {
+				messages[i] = openai.UserMessage(contentParts(msg.Content, request.Images))
+				imagesSent = true
+			} else {
+				messages[i] = openai.UserMessage(msg.Content)
+			}
 		case "assistant":
 			messages[i] = openai.AssistantMessage(msg.Content)
 		case "system":
@@ -75,64 +240,388 @@ func (s *OpenAIService) SendChatCompletion(ctx context.Context, request models.B
 		}
 	}
 
-	// Prepare the chat completion request
 	chatRequest := openai.ChatCompletionNewParams{
 		Messages: messages,
 		Model:    request.Model,
 	}
 
+	// Reasoning models reject max_tokens
 	if request.MaxTokens > 0 {
-		chatRequest.MaxTokens = openai.Int(int64(request.MaxTokens))
+		if s.provider.IsReasoningModel(request.Model) {
+			chatRequest.MaxCompletionTokens = openai.Int(int64(request.MaxTokens))
+		} else {
+			chatRequest.MaxTokens = openai.Int(int64(request.MaxTokens))
+		}
+	}
+
+	if request.Temperature != nil {
+		chatRequest.Temperature = openai.Float(*request.Temperature)
+	}
+	if request.TopP != nil {
+		chatRequest.TopP = openai.Float(*request.TopP)
+	}
+	if request.Seed != nil {
+		chatRequest.Seed = openai.Int(*request.Seed)
+	}
+	if len(request.Stop) > 0 {
+		chatRequest.Stop = openai.ChatCompletionNewParamsStopUnion{OfStringArray: request.Stop}
+	}
+	if request.FrequencyPenalty != nil {
+		chatRequest.FrequencyPenalty = openai.Float(*request.FrequencyPenalty)
+	}
+	if request.PresencePenalty != nil {
+		chatRequest.PresencePenalty = openai.Float(*request.PresencePenalty)
+	}
+	if request.N > 1 {
+		chatRequest.N = openai.Int(int64(request.N))
+	}
+	if request.Logprobs {
+		chatRequest.Logprobs = openai.Bool(true)
+		if request.TopLogprobs != nil {
+			chatRequest.TopLogprobs = openai.Int(*request.TopLogprobs)
+		}
+	}
+
+	switch request.ResponseFormat {
+	case models.ResponseFormatText:
+		chatRequest.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfText: &openai.ResponseFormatTextParam{},
+		}
+	case models.ResponseFormatJSONObject:
+		chatRequest.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfJSONObject: &openai.ResponseFormatJSONObjectParam{},
+		}
+	case models.ResponseFormatJSONSchema:
+		chatRequest.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfJSONSchema: &openai.ResponseFormatJSONSchemaParam{
+				JSONSchema: openai.ResponseFormatJSONSchemaJSONSchemaParam{
+					Name:   "benchmark_response",
+					Schema: request.JSONSchema,
+				},
+			},
+		}
+	}
+
+	return chatRequest
+}
+
+// buildStreamRequest converts a benchmark request into streaming chat
+// completion params
+func (s *OpenAIService) buildStreamRequest(request models.BenchmarkRequest) openai.ChatCompletionNewParams {
+	chatRequest := s.buildChatRequest(request)
+
+	// Cached and reasoning tokens only come with the usage, and image tokens
+	// can't be counted locally, so always ask for it in the final chunk
+	chatRequest.StreamOptions = openai.ChatCompletionStreamOptionsParam{IncludeUsage: openai.Bool(true)}
+	return chatRequest
+}
+
+// RequestBody returns the JSON body the request is sent to the provider with
+func (s *OpenAIService) RequestBody(request models.BenchmarkRequest) ([]byte, error) {
+	if len(request.RawBody) > 0 {
+		return rawBodyFor(request.RawBody, request.Model)
+	}
+	if !request.Stream {
+		return json.Marshal(s.buildChatRequest(request))
+	}
+
+	// The client sets stream itself when sending a streaming request
+	body, err := json.Marshal(s.buildStreamRequest(request))
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+	fields["stream"] = json.RawMessage("true")
+	return json.Marshal(fields)
+}
+
+// contentParts builds a multimodal message content from text and images
+func contentParts(text string, images []string) []openai.ChatCompletionContentPartUnionParam {
+	parts := []openai.ChatCompletionContentPartUnionParam{openai.TextContentPart(text)}
+	for _, image := range images {
+		parts = append(parts, openai.ImageContentPart(openai.ChatCompletionContentPartImageImageURLParam{URL: image}))
+	}
+	return parts
+}
+
+// recordedHeaders are the response headers kept on each result, besides
+// any x-ratelimit-* header
+var recordedHeaders = []string{"x-request-id", "request-id", "retry-after", "openai-processing-ms"}
+
+// responseHeaders extracts the rate-limit and request id headers from a
+// provider response, or nil if there are none
+func responseHeaders(resp *http.Response) map[string]string {
+	if resp == nil {
+		return nil
+	}
+
+	headers := make(map[string]string)
+	for key, values := range resp.Header {
+		name := strings.ToLower(key)
+		if len(values) == 0 {
+			continue
+		}
+		if strings.HasPrefix(name, "x-ratelimit-") || slices.Contains(recordedHeaders, name) {
+			headers[name] = values[0]
+		}
+	}
+
+	if len(headers) == 0 {
+		return nil
+	}
+	return headers
+}
+
+// SendChatCompletion sends a chat completion request and measures performance
+func (s *OpenAIService) SendChatCompletion(ctx context.Context, request models.BenchmarkRequest) models.BenchmarkResult {
+	if len(request.RawBody) > 0 {
+		return s.sendRawChatCompletion(ctx, request)
 	}
 
+	start := time.Now()
+
+	result := models.BenchmarkResult{
+		Provider:  s.provider.Name,
+		ModelName: request.Model,
+		StartedAt: start,
+	}
+
+	// Create context with timeout
+	timeoutCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	// Prepare the chat completion request
+	chatRequest := s.buildChatRequest(request)
+
 	// Send the request
-	response, err := s.client.Chat.Completions.New(timeoutCtx, chatRequest)
+	logf("%s: starting request (model: %s)", s.provider.Name, request.Model)
+	var httpResponse *http.Response
+	timer := newNetworkTimer(start)
+	response, err := s.client.Chat.Completions.New(timer.trace(timeoutCtx), chatRequest, option.WithResponseInto(&httpResponse))
 
 	result.ResponseTime = time.Since(start)
+	result.ResponseHeaders = responseHeaders(httpResponse)
+	timer.record(&result)
 
 	if err != nil {
+		logf("%s: request failed after %v (model: %s): %v", s.provider.Name, result.ResponseTime, request.Model, err)
 		result.Success = false
 		result.Error = err.Error()
+		result.ErrorKind = errorKind(err)
 		return result
 	}
 
+	logf("%s: request completed in %v (model: %s)", s.provider.Name, result.ResponseTime, request.Model)
+
 	result.Success = true
+	result.ResponseBytes = len(response.RawJSON())
+	result.ByteThroughput = byteThroughput(result.ResponseBytes, result.ResponseTime)
+	result.CachedInputTokens = int(response.Usage.PromptTokensDetails.CachedTokens)
+	result.ReasoningTokens = int(response.Usage.CompletionTokensDetails.ReasoningTokens)
 
 	// Extract response content
-	if len(response.Choices) > 0 && response.Choices[0].Message.Content != "" {
+	if len(response.Choices) > 0 {
 		result.Response = response.Choices[0].Message.Content
+		result.FinishReason = response.Choices[0].FinishReason
+		result.Logprobs = len(response.Choices[0].Logprobs.Content) > 0
+	}
+	if len(response.Choices) > 1 {
+		result.Choices = len(response.Choices)
 	}
 
-	// Calculate token usage using our token counter
-	if s.tokenCounter != nil {
+	// Some gateways report errors in the body of a 2xx response
+	if s.strict {
+		if reason := invalidResponse(response.RawJSON(), result.Response); reason != "" {
+			s.rejectResponse(&result, request.Model, reason)
+			return result
+		}
+	}
+
+	// Count tokens locally with the model's tokenizer. Images can't be
+	// counted locally, and without a known tokenizer a local count is a
+	// guess, so the provider's usage wins in both cases when reported
+	countAs, knownTokenizer := s.provider.TokenizerModel(request.Model)
+	usageReported := response.Usage.TotalTokens > 0
+	if usageReported && (len(request.Images) > 0 || !knownTokenizer) {
+		result.TokensUsed = int(response.Usage.TotalTokens)
+		result.InputTokens = int(response.Usage.PromptTokens)
+		result.OutputTokens = int(response.Usage.CompletionTokens)
+	} else if s.tokenCounter != nil {
 		// Count input tokens
-		inputTokens := s.tokenCounter.CountChatCompletionTokens(request.Messages, request.Model)
+		inputTokens := s.tokenCounter.CountChatCompletionTokens(request.Messages, countAs)
 		
-		// Count output tokens
+		// Count output tokens, across every choice when several were asked for
 		outputTokens := 0
-		if result.Response != "" {
-			outputTokens = s.tokenCounter.CountTokens(result.Response)
+		for _, choice := range response.Choices {
+			if choice.Message.Content != "" {
+				outputTokens += s.countTokens(choice.Message.Content, countAs, knownTokenizer)
+			}
 		}
 		
 		result.TokensUsed = inputTokens + outputTokens
-	} else if response.Usage.TotalTokens > 0 {
+		result.InputTokens = inputTokens
+		result.OutputTokens = outputTokens
+		result.TokensEstimated = !knownTokenizer
+	} else if usageReported {
 		// Fallback to OpenAI's token count if our counter is not available
 		result.TokensUsed = int(response.Usage.TotalTokens)
+		result.InputTokens = int(response.Usage.PromptTokens)
+		result.OutputTokens = int(response.Usage.CompletionTokens)
 	}
 
 	return result
 }
 
-// TestConnection tests the connection to the provider
-func (s *OpenAIService) TestConnection(ctx context.Context) error {
+// sendRawChatCompletion posts the request's raw body as is, apart from its
+// model, and measures performance. Token counts come from the provider's
+// usage, as the body may hold anything
+func (s *OpenAIService) sendRawChatCompletion(ctx context.Context, request models.BenchmarkRequest) models.BenchmarkResult {
+	start := time.Now()
+
+	result := models.BenchmarkResult{
+		Provider:  s.provider.Name,
+		ModelName: request.Model,
+		StartedAt: start,
+	}
+
+	body, err := rawBodyFor(request.RawBody, request.Model)
+	if err != nil {
+		result.Error = err.Error()
+		result.ErrorKind = models.ErrorKindOther
+		return result
+	}
+
 	timeoutCtx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
+	logf("%s: starting raw request (model: %s)", s.provider.Name, request.Model)
+	var response openai.ChatCompletion
+	var httpResponse *http.Response
+	timer := newNetworkTimer(start)
+	err = s.client.Post(timer.trace(timeoutCtx), "chat/completions", nil, &response,
+		option.WithRequestBody("application/json", body), option.WithResponseInto(&httpResponse))
+
+	result.ResponseTime = time.Since(start)
+	result.ResponseHeaders = responseHeaders(httpResponse)
+	timer.record(&result)
+
+	if err != nil {
+		logf("%s: raw request failed after %v (model: %s): %v", s.provider.Name, result.ResponseTime, request.Model, err)
+		result.Error = err.Error()
+		result.ErrorKind = errorKind(err)
+		return result
+	}
+
+	logf("%s: raw request completed in %v (model: %s)", s.provider.Name, result.ResponseTime, request.Model)
+
+	result.Success = true
+	result.ResponseBytes = len(response.RawJSON())
+	result.ByteThroughput = byteThroughput(result.ResponseBytes, result.ResponseTime)
+	result.CachedInputTokens = int(response.Usage.PromptTokensDetails.CachedTokens)
+	result.ReasoningTokens = int(response.Usage.CompletionTokensDetails.ReasoningTokens)
+	result.TokensUsed = int(response.Usage.TotalTokens)
+	result.InputTokens = int(response.Usage.PromptTokens)
+	result.OutputTokens = int(response.Usage.CompletionTokens)
+	if len(response.Choices) > 0 {
+		result.Response = response.Choices[0].Message.Content
+		result.FinishReason = response.Choices[0].FinishReason
+		result.Logprobs = len(response.Choices[0].Logprobs.Content) > 0
+	}
+	if len(response.Choices) > 1 {
+		result.Choices = len(response.Choices)
+	}
+
+	if s.strict {
+		if reason := invalidResponse(response.RawJSON(), result.Response); reason != "" {
+			s.rejectResponse(&result, request.Model, reason)
+		}
+	}
+
+	return result
+}
+
+// rawBodyFor sets the model of a raw request body, replacing the value of
+// its top-level "model" members or adding one when there is none. The rest
+// of the body is sent byte for byte: keys keep their order, numbers and
+// whitespace their spelling, and duplicate keys are left for the provider
+func rawBodyFor(raw json.RawMessage, model string) ([]byte, error) {
+	encodedModel, err := json.Marshal(model)
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid(raw) {
+		return nil, fmt.Errorf("invalid raw body: not valid JSON")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, fmt.Errorf("invalid raw body: not a JSON object")
+	}
+	objectStart := decoder.InputOffset()
+
+	// Offsets of the values of the model members, in order
+	var spans [][2]int64
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("invalid raw body: %w", err)
+		}
+		keyEnd := decoder.InputOffset()
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, fmt.Errorf("invalid raw body: %w", err)
+		}
+		if key == "model" {
+			// The value starts after the colon and any whitespace
+			valueStart := keyEnd + int64(bytes.IndexByte(raw[keyEnd:], ':')) + 1
+			valueStart += int64(len(raw[valueStart:]) - len(bytes.TrimLeft(raw[valueStart:], " \t\r\n")))
+			spans = append(spans, [2]int64{valueStart, decoder.InputOffset()})
+		}
+	}
+
+	var body bytes.Buffer
+	if len(spans) == 0 {
+		body.Write(raw[:objectStart])
+		body.WriteString(`"model":`)
+		body.Write(encodedModel)
+		if len(bytes.TrimSpace(raw[objectStart:])) > 1 {
+			body.WriteByte(',')
+		}
+		body.Write(raw[objectStart:])
+		return body.Bytes(), nil
+	}
+
+	last := int64(0)
+	for _, span := range spans {
+		body.Write(raw[last:span[0]])
+		body.Write(encodedModel)
+		last = span[1]
+	}
+	body.Write(raw[last:])
+	return body.Bytes(), nil
+}
+
+// connectionTestAttempts is how many times a connection test is tried
+// before giving up, so a slow cold start isn't mistaken for an outage
+const connectionTestAttempts = 2
+
+// TestConnection tests the connection to the provider, retrying failures
+// that may be transient
+func (s *OpenAIService) TestConnection(ctx context.Context) error {
 	// Use the first model for connection testing
 	if len(s.provider.Models) == 0 {
 		return fmt.Errorf("no models configured for provider %s", s.provider.Name)
 	}
 
+	// Azure deployments are addressed by deployment name, not model
+	model := s.provider.Models[0]
+	if s.provider.IsAzure() {
+		model = s.provider.Deployment
+	}
+
 	// Send a simple test message
 	testRequest := models.BenchmarkRequest{
 		Messages: []models.ChatMessage{
@@ -141,113 +630,306 @@ func (s *OpenAIService) TestConnection(ctx context.Context) error {
 				Content: "Hello, this is a connection test. Please respond with 'OK'.",
 			},
 		},
-		Model:     s.provider.Models[0],
+		Model:     model,
 		MaxTokens: 20,
 	}
+	chatRequest := s.buildChatRequest(testRequest)
 
-	result := s.SendChatCompletion(timeoutCtx, testRequest)
-	if !result.Success {
-		return fmt.Errorf("connection test failed: %s", result.Error)
+	var err error
+	for attempt := 1; attempt <= connectionTestAttempts; attempt++ {
+		err = s.testConnectionOnce(ctx, chatRequest)
+		if err == nil {
+			return nil
+		}
+
+		// Retrying won't fix bad credentials or a wrong model name
+		if errors.Is(err, ErrAuthFailed) || errors.Is(err, ErrModelNotFound) || ctx.Err() != nil {
+			return err
+		}
+		logf("%s: connection test attempt %d/%d failed: %v", s.provider.Name, attempt, connectionTestAttempts, err)
+	}
+
+	return fmt.Errorf("%w (after %d attempts)", err, connectionTestAttempts)
+}
+
+// testConnectionOnce sends a single connection test request
+func (s *OpenAIService) testConnectionOnce(ctx context.Context, chatRequest openai.ChatCompletionNewParams) error {
+	timeoutCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	if _, err := s.client.Chat.Completions.New(timeoutCtx, chatRequest); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+			return fmt.Errorf("%w after %v", ErrTimeout, s.timeout)
+		}
+		return classifyError(err)
 	}
 
 	return nil
 }
 
+// Connection test failure kinds, so callers can tell a bad model name
+// apart from bad credentials or an unreachable endpoint
+var (
+	ErrAuthFailed    = errors.New("auth failed")
+	ErrUnreachable   = errors.New("unreachable")
+	ErrModelNotFound = errors.New("model not found")
+	ErrTimeout       = errors.New("timed out")
+	ErrRefused       = errors.New("connection refused")
+)
+
+// VerifyModels lists the provider's models and checks that every configured
+// model is available
+func (s *OpenAIService) VerifyModels(ctx context.Context) error {
+	// Azure deployments do not expose a model listing under the deployment URL
+	if s.provider.IsAzure() {
+		return nil
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	available := make(map[string]bool)
+	iter := s.client.Models.ListAutoPaging(timeoutCtx)
+	for iter.Next() {
+		available[iter.Current().ID] = true
+	}
+	if err := iter.Err(); err != nil {
+		return classifyError(err)
+	}
+
+	var missing []string
+	for _, model := range s.provider.Models {
+		if !available[model] {
+			missing = append(missing, model)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrModelNotFound, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// classifyError wraps an API error with the kind of failure it represents
+func classifyError(err error) error {
+	var apiErr *openai.Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return fmt.Errorf("%w: %v", ErrAuthFailed, err)
+		case http.StatusNotFound:
+			return fmt.Errorf("%w: %v", ErrModelNotFound, err)
+		}
+		return err
+	}
+
+	var netErr net.Error
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return fmt.Errorf("%w: %v", ErrRefused, err)
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return fmt.Errorf("%w: %v", ErrTimeout, err)
+	}
+	return fmt.Errorf("%w: %v", ErrUnreachable, err)
+}
+
+// errorKind classifies a request error for the per-kind error breakdown
+func errorKind(err error) string {
+	var apiErr *openai.Error
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden:
+			return models.ErrorKindAuth
+		case apiErr.StatusCode == http.StatusTooManyRequests:
+			return models.ErrorKindRateLimit
+		case apiErr.StatusCode == http.StatusRequestTimeout || apiErr.StatusCode == http.StatusGatewayTimeout:
+			return models.ErrorKindTimeout
+		case apiErr.StatusCode >= 500:
+			return models.ErrorKindServer
+		}
+		return models.ErrorKindOther
+	}
+
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return models.ErrorKindTimeout
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return models.ErrorKindTimeout
+	case errors.As(err, &netErr), errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.ECONNRESET):
+		return models.ErrorKindNetwork
+	}
+	return models.ErrorKindOther
+}
+
 // SendChatCompletionStream sends a streaming chat completion request and measures performance
 func (s *OpenAIService) SendChatCompletionStream(ctx context.Context, request models.BenchmarkRequest) models.BenchmarkResult {
+	return s.StreamChatCompletion(ctx, request, nil)
+}
+
+// StreamChatCompletion works like SendChatCompletionStream, also passing
+// every content chunk to onChunk as it arrives when onChunk is not nil
+func (s *OpenAIService) StreamChatCompletion(ctx context.Context, request models.BenchmarkRequest, onChunk func(string)) models.BenchmarkResult {
 	start := time.Now()
 
 	result := models.BenchmarkResult{
 		Provider:    s.provider.Name,
+		ModelName:   request.Model,
+		StartedAt:   start,
 		IsStreaming: true,
 	}
 
-	// Create context with timeout
+	// Create context with timeout, which a stalled stream cancels early
 	timeoutCtx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
-
-	// Convert our messages to OpenAI format
-	messages := make([]openai.ChatCompletionMessageParamUnion, len(request.Messages))
-	for i, msg := range request.Messages {
-		switch msg.Role {
-		case "user":
-			messages[i] = openai.UserMessage(msg.Content)
-		case "assistant":
-			messages[i] = openai.AssistantMessage(msg.Content)
-		case "system":
-			messages[i] = openai.SystemMessage(msg.Content)
-		default:
-			messages[i] = openai.UserMessage(msg.Content)
-		}
-	}
+	streamCtx, cancelStream := context.WithCancelCause(timeoutCtx)
+	defer cancelStream(nil)
 
 	// Prepare the streaming chat completion request
-	chatRequest := openai.ChatCompletionNewParams{
-		Messages: messages,
-		Model:    request.Model,
-	}
-
-	if request.MaxTokens > 0 {
-		chatRequest.MaxTokens = openai.Int(int64(request.MaxTokens))
-	}
+	chatRequest := s.buildStreamRequest(request)
 
 	// Send the streaming request
-	stream := s.client.Chat.Completions.NewStreaming(timeoutCtx, chatRequest)
+	logf("%s: starting streaming request (model: %s)", s.provider.Name, request.Model)
+	var httpResponse *http.Response
+	timer := newNetworkTimer(start)
+	stream := s.client.Chat.Completions.NewStreaming(timer.trace(streamCtx), chatRequest, option.WithResponseInto(&httpResponse))
 	defer stream.Close()
+	result.ResponseHeaders = responseHeaders(httpResponse)
+
+	// The stall window starts once the stream is open and restarts with
+	// every chunk, telling a hung stream apart from a slow first token
+	var stallTimer *time.Timer
+	if s.stallTimeout > 0 {
+		stallTimer = time.AfterFunc(s.stallTimeout, func() { cancelStream(errStreamStall) })
+		defer stallTimer.Stop()
+	}
 
 	var responseContent string
+	var otherChoices map[int64]string // content of the choices after the first, by index
 	var chunkCount int
+	var responseBytes int
+	var usage openai.CompletionUsage
 	var firstTokenTime time.Time
 	var streamEndTime time.Time
 	firstToken := true
 
 	// Process the stream
 	for stream.Next() {
+		if stallTimer != nil {
+			stallTimer.Reset(s.stallTimeout)
+		}
 		chunk := stream.Current()
+		responseBytes += len(chunk.RawJSON())
+		if chunk.Usage.TotalTokens > 0 {
+			usage = chunk.Usage
+		}
 		
-		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+		// With n > 1, chunks of every choice are interleaved; the first
+		// choice is the response, the others only count towards tokens
+		for _, choice := range chunk.Choices {
+			if choice.Index == 0 && choice.FinishReason != "" {
+				result.FinishReason = choice.FinishReason
+			}
+			if len(choice.Logprobs.Content) > 0 {
+				result.Logprobs = true
+			}
+			if choice.Delta.Content == "" {
+				continue
+			}
+			
 			if firstToken {
 				firstTokenTime = time.Now()
 				result.TimeToFirstToken = firstTokenTime.Sub(start)
 				firstToken = false
 			}
 			
-			responseContent += chunk.Choices[0].Delta.Content
+			if choice.Index > 0 {
+				if otherChoices == nil {
+					otherChoices = make(map[int64]string)
+				}
+				otherChoices[choice.Index] += choice.Delta.Content
+				continue
+			}
+			responseContent += choice.Delta.Content
+			if onChunk != nil {
+				onChunk(choice.Delta.Content)
+			}
 			chunkCount++
 		}
 	}
 	
 	// Mark the end of streaming
 	streamEndTime = time.Now()
+	timer.record(&result)
 
 	// Check for streaming errors
 	if err := stream.Err(); err != nil {
 		result.Success = false
 		result.Error = err.Error()
+		result.ErrorKind = errorKind(err)
+		if errors.Is(context.Cause(streamCtx), errStreamStall) {
+			result.Error = fmt.Sprintf("%v: no chunk received for %v", errStreamStall, s.stallTimeout)
+			result.ErrorKind = models.ErrorKindStreamStall
+		}
 		result.ResponseTime = time.Since(start)
+		logf("%s: streaming request failed after %v (model: %s): %v", s.provider.Name, result.ResponseTime, request.Model, err)
 		return result
 	}
 
 	// Calculate final metrics
 	result.Success = true
 	result.ResponseTime = time.Since(start)
+	result.EmptyResponse = responseContent == ""
+	if s.strict && result.EmptyResponse {
+		s.rejectResponse(&result, request.Model, "no content")
+		return result
+	}
+	result.ResponseBytes = responseBytes
+	result.ByteThroughput = byteThroughput(responseBytes, result.ResponseTime)
+	result.CachedInputTokens = int(usage.PromptTokensDetails.CachedTokens)
+	result.ReasoningTokens = int(usage.CompletionTokensDetails.ReasoningTokens)
+	logf("%s: streaming request completed in %v (model: %s, ttft: %v)", s.provider.Name, result.ResponseTime, request.Model, result.TimeToFirstToken)
 	result.Response = responseContent
+	if len(otherChoices) > 0 {
+		result.Choices = len(otherChoices) + 1
+	}
 	
 	// Calculate proper token counts using our token counter
 	var totalTokens int
 	var outputTokens int
 	
-	if s.tokenCounter != nil {
+	countAs, knownTokenizer := s.provider.TokenizerModel(request.Model)
+	if usage.TotalTokens > 0 && (len(request.Images) > 0 || !knownTokenizer) {
+		// Images can't be counted locally, and without a known tokenizer a
+		// local count is a guess, so use the provider's usage when reported
+		outputTokens = int(usage.CompletionTokens)
+		totalTokens = int(usage.TotalTokens)
+		result.TokensUsed = totalTokens
+		result.InputTokens = int(usage.PromptTokens)
+		result.OutputTokens = outputTokens
+	} else if s.tokenCounter != nil {
 		// Count input tokens
-		inputTokens := s.tokenCounter.CountChatCompletionTokens(request.Messages, request.Model)
+		inputTokens := s.tokenCounter.CountChatCompletionTokens(request.Messages, countAs)
 		
 		// Count output tokens from the complete response
 		if responseContent != "" {
-			outputTokens = s.tokenCounter.CountTokens(responseContent)
+			outputTokens = s.countTokens(responseContent, countAs, knownTokenizer)
+		}
+		for _, content := range otherChoices {
+			outputTokens += s.countTokens(content, countAs, knownTokenizer)
 		}
 		
 		totalTokens = inputTokens + outputTokens
 		result.TokensUsed = totalTokens
+		result.InputTokens = inputTokens
+		result.OutputTokens = outputTokens
+		result.TokensEstimated = !knownTokenizer
+	} else if usage.TotalTokens > 0 {
+		// Fallback to the provider's usage if our counter is not available
+		outputTokens = int(usage.CompletionTokens)
+		result.TokensUsed = int(usage.TotalTokens)
+		result.InputTokens = int(usage.PromptTokens)
+		result.OutputTokens = outputTokens
 	}
 	
 	// Set streaming-specific metrics
@@ -269,6 +951,57 @@ func (s *OpenAIService) SendChatCompletionStream(ctx context.Context, request mo
 	return result
 }
 
+// rejectResponse fails a result whose response strict checking rejected
+func (s *OpenAIService) rejectResponse(result *models.BenchmarkResult, model, reason string) {
+	result.Success = false
+	result.Error = fmt.Sprintf("%v: %s", errInvalidResponse, reason)
+	result.ErrorKind = models.ErrorKindInvalidResponse
+	logf("%s: rejected response after %v (model: %s): %s", s.provider.Name, result.ResponseTime, model, reason)
+}
+
+// invalidResponse returns why strict checking rejects a 2xx response, from
+// its raw body and content, or "" when it is fine
+func invalidResponse(raw, content string) string {
+	if message := bodyError(raw); message != "" {
+		return "error in body: " + message
+	}
+	if content == "" {
+		return "no content"
+	}
+	return ""
+}
+
+// bodyError returns the message of an error field in a response body, as
+// either a string or an object with a message, or "" when there is none
+func bodyError(raw string) string {
+	var body struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(raw), &body); err != nil || len(body.Error) == 0 || string(body.Error) == "null" {
+		return ""
+	}
+
+	var message string
+	if err := json.Unmarshal(body.Error, &message); err == nil {
+		return message
+	}
+	var detail struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body.Error, &detail); err == nil && detail.Message != "" {
+		return detail.Message
+	}
+	return string(body.Error)
+}
+
+// byteThroughput returns the bytes per second received over a duration
+func byteThroughput(bytes int, duration time.Duration) float64 {
+	if bytes == 0 || duration <= 0 {
+		return 0
+	}
+	return float64(bytes) / duration.Seconds()
+}
+
 // GetProviderInfo returns information about the provider
 func (s *OpenAIService) GetProviderInfo() models.Provider {
 	return s.provider
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"llmbench/internal/models"
+)
+
+func TestRawBodyForReplacesOnlyTheModel(t *testing.T) {
+	raw := `{
+  "temperature" : 1.0e0,
+  "model":	"placeholder",
+  "seed": 12345678901234567890,
+  "messages": [{"role": "user", "content": "café", "model": "nested"}],
+  "model" : "duplicate"
+}
+`
+	want := `{
+  "temperature" : 1.0e0,
+  "model":	"gpt-4o",
+  "seed": 12345678901234567890,
+  "messages": [{"role": "user", "content": "café", "model": "nested"}],
+  "model" : "gpt-4o"
+}
+`
+
+	got, err := rawBodyFor([]byte(raw), "gpt-4o")
+	if err != nil {
+		t.Fatalf("rawBodyFor: %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("rawBodyFor =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRawBodyForAddsMissingModel(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{`{"stream": false}`, `{"model":"gpt-4o","stream": false}`},
+		{`{ }`, `{"model":"gpt-4o" }`},
+	}
+
+	for _, test := range tests {
+		got, err := rawBodyFor([]byte(test.raw), "gpt-4o")
+		if err != nil {
+			t.Fatalf("rawBodyFor(%s): %v", test.raw, err)
+		}
+		if string(got) != test.want {
+			t.Errorf("rawBodyFor(%s) = %s, want %s", test.raw, got, test.want)
+		}
+	}
+}
+
+func TestRawBodyForRejectsNonObjects(t *testing.T) {
+	for _, raw := range []string{`[]`, `"model"`, `{"model":`} {
+		if _, err := rawBodyFor([]byte(raw), "gpt-4o"); err == nil {
+			t.Errorf("rawBodyFor(%s) succeeded, want an error", raw)
+		}
+	}
+}
+
+func TestStreamRequestsAskForUsage(t *testing.T) {
+	service := &OpenAIService{provider: models.Provider{Name: "test"}}
+	request := models.BenchmarkRequest{
+		Model:    "gpt-4o",
+		Messages: []models.ChatMessage{{Role: "user", Content: "Hello"}},
+		Stream:   true,
+	}
+
+	body, err := service.RequestBody(request)
+	if err != nil {
+		t.Fatalf("RequestBody: %v", err)
+	}
+	var fields struct {
+		StreamOptions struct {
+			IncludeUsage bool `json:"include_usage"`
+		} `json:"stream_options"`
+	}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		t.Fatalf("unmarshal %s: %v", body, err)
+	}
+	if !fields.StreamOptions.IncludeUsage {
+		t.Errorf("stream request body %s doesn't set stream_options.include_usage", body)
+	}
+}
+
+func TestStreamPrefersReportedUsageWithoutTokenizer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range []string{
+			`{"id":"1","object":"chat.completion.chunk","created":0,"model":"llama-3-8b","choices":[{"index":0,"delta":{"content":"Hi there"}}]}`,
+			`{"id":"1","object":"chat.completion.chunk","created":0,"model":"llama-3-8b","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`,
+			`{"id":"1","object":"chat.completion.chunk","created":0,"model":"llama-3-8b","choices":[],"usage":{"prompt_tokens":7,"completion_tokens":3,"total_tokens":10}}`,
+		} {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	service := newOpenAIService(models.Provider{Name: "local", BaseURL: server.URL, APIKey: "key"}, 5*time.Second, nil)
+	result := service.StreamChatCompletion(context.Background(), models.BenchmarkRequest{
+		Model:    "llama-3-8b",
+		Messages: []models.ChatMessage{{Role: "user", Content: "Hello"}},
+		Stream:   true,
+	}, nil)
+
+	if !result.Success {
+		t.Fatalf("stream failed: %s", result.Error)
+	}
+	if result.InputTokens != 7 || result.OutputTokens != 3 || result.TokensUsed != 10 {
+		t.Errorf("tokens = %d in, %d out, %d total, want the reported 7, 3 and 10", result.InputTokens, result.OutputTokens, result.TokensUsed)
+	}
+	if result.TokensEstimated {
+		t.Error("reported usage flagged as estimated")
+	}
+}
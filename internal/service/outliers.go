@@ -0,0 +1,76 @@
+package service
+
+import (
+	"math"
+	"slices"
+
+	"llmbench/internal/models"
+)
+
+// outlierMADs is how many scaled median absolute deviations a response time
+// must be from its provider/model's median for the request to be an outlier
+const outlierMADs = 3
+
+// madScale scales a median absolute deviation to match the standard
+// deviation of normally distributed values, so that outlierMADs reads as a
+// number of standard deviations
+const madScale = 1.4826
+
+// minOutlierSamples is the number of successful requests below which no
+// request is flagged, as a median of fewer says little
+const minOutlierSamples = 5
+
+// MarkOutliers flags the outliers among the results of every
+// provider/model, clearing the flag on every other request
+func MarkOutliers(results map[string][]models.BenchmarkResult) {
+	for _, providerResults := range results {
+		for i, isOutlier := range outliers(providerResults) {
+			providerResults[i].IsOutlier = isOutlier
+		}
+	}
+}
+
+// outliers reports which results are outliers: the successful requests
+// whose response time is more than outlierMADs scaled median absolute
+// deviations from the median of the successful requests. Unlike a mean and
+// standard deviation, which a single slow request inflates enough to hide
+// itself in small runs, the median and its deviation barely move with it
+func outliers(results []models.BenchmarkResult) []bool {
+	flags := make([]bool, len(results))
+
+	var times []float64
+	for _, result := range results {
+		if result.Success {
+			times = append(times, float64(result.ResponseTime))
+		}
+	}
+	if len(times) < minOutlierSamples {
+		return flags
+	}
+
+	median := medianOf(times)
+	deviations := make([]float64, len(times))
+	for i, t := range times {
+		deviations[i] = math.Abs(t - median)
+	}
+	mad := madScale * medianOf(deviations)
+	if mad == 0 {
+		return flags
+	}
+
+	for i, result := range results {
+		flags[i] = result.Success && math.Abs(float64(result.ResponseTime)-median) > outlierMADs*mad
+	}
+	return flags
+}
+
+// medianOf returns the median of the values, leaving them unsorted
+func medianOf(values []float64) float64 {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
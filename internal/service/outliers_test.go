@@ -0,0 +1,67 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"llmbench/internal/models"
+)
+
+func resultsWithTimes(times ...time.Duration) []models.BenchmarkResult {
+	results := make([]models.BenchmarkResult, len(times))
+	for i, t := range times {
+		results[i] = models.BenchmarkResult{Success: true, ResponseTime: t}
+	}
+	return results
+}
+
+func TestOutliersFlagsSlowRequestAtDefaultRequestCount(t *testing.T) {
+	results := resultsWithTimes(
+		100*time.Millisecond, 104*time.Millisecond, 98*time.Millisecond, 101*time.Millisecond, 97*time.Millisecond,
+		103*time.Millisecond, 99*time.Millisecond, 102*time.Millisecond, 100*time.Millisecond, 2*time.Second,
+	)
+
+	flags := outliers(results)
+	for i, flagged := range flags {
+		if want := i == 9; flagged != want {
+			t.Errorf("request %d (%v): outlier = %v, want %v", i, results[i].ResponseTime, flagged, want)
+		}
+	}
+}
+
+func TestOutliersIgnoresFailuresAndSmallSamples(t *testing.T) {
+	results := resultsWithTimes(100*time.Millisecond, 101*time.Millisecond, 99*time.Millisecond, 5*time.Second)
+	for i, flagged := range outliers(results) {
+		if flagged {
+			t.Errorf("request %d flagged with only %d successes", i, len(results))
+		}
+	}
+
+	results = resultsWithTimes(
+		100*time.Millisecond, 104*time.Millisecond, 98*time.Millisecond, 101*time.Millisecond, 97*time.Millisecond, 5*time.Second,
+	)
+	results[5].Success = false
+	for i, flagged := range outliers(results) {
+		if flagged {
+			t.Errorf("request %d flagged, want none: the slow request failed", i)
+		}
+	}
+}
+
+func TestGenerateSummaryLeavesResultsUnchanged(t *testing.T) {
+	results := resultsWithTimes(
+		100*time.Millisecond, 104*time.Millisecond, 98*time.Millisecond, 101*time.Millisecond, 97*time.Millisecond,
+		103*time.Millisecond, 99*time.Millisecond, 102*time.Millisecond, 100*time.Millisecond, 2*time.Second,
+	)
+	run := &Run{Results: map[string][]models.BenchmarkResult{"p/m": results}}
+
+	summaries := (&BenchmarkService{}).GenerateSummary(run)
+	if got := summaries["p/m"].Outliers; got != 1 {
+		t.Errorf("Outliers = %d, want 1", got)
+	}
+	for i, result := range run.Results["p/m"] {
+		if result.IsOutlier {
+			t.Errorf("GenerateSummary flagged request %d in the caller's results", i)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"math"
+	"time"
+
+	"llmbench/internal/models"
+)
+
+// ScoreSummaries sets a weighted 0-100 score on every summary. Each metric
+// is normalized against the best performer of the run, so the best provider
+// on every metric scores 100. Throughput only counts for streaming runs.
+// The score is then scaled down by the provider's own success rate along
+// the weights' error penalty curve, since a provider failing 40% of its
+// requests shouldn't outrank a reliable one by being fast on the rest.
+func ScoreSummaries(summaries map[string]models.BenchmarkSummary, weights models.ScoreWeights) {
+	var bestLatency time.Duration
+	var bestThroughput, bestSuccessRate float64
+	for _, summary := range summaries {
+		if summary.SuccessfulReqs > 0 && (bestLatency == 0 || summary.AvgResponseTime < bestLatency) {
+			bestLatency = summary.AvgResponseTime
+		}
+		if summary.AvgTokenThroughput > bestThroughput {
+			bestThroughput = summary.AvgTokenThroughput
+		}
+		if successRate := 100 - summary.ErrorRate; successRate > bestSuccessRate {
+			bestSuccessRate = successRate
+		}
+	}
+
+	for key, summary := range summaries {
+		var score, totalWeight float64
+
+		if weights.Latency > 0 {
+			latency := 0.0
+			if summary.SuccessfulReqs > 0 && summary.AvgResponseTime > 0 {
+				latency = float64(bestLatency) / float64(summary.AvgResponseTime)
+			}
+			score += weights.Latency * latency
+			totalWeight += weights.Latency
+		}
+
+		if weights.Throughput > 0 && bestThroughput > 0 {
+			score += weights.Throughput * summary.AvgTokenThroughput / bestThroughput
+			totalWeight += weights.Throughput
+		}
+
+		if weights.ErrorRate > 0 && bestSuccessRate > 0 {
+			score += weights.ErrorRate * (100 - summary.ErrorRate) / bestSuccessRate
+			totalWeight += weights.ErrorRate
+		}
+
+		if totalWeight > 0 {
+			summary.Score = 100 * score / totalWeight * errorPenalty(summary, weights.ErrorPenalty)
+		}
+		summaries[key] = summary
+	}
+}
+
+// errorPenalty returns the factor a summary's score is multiplied by for its
+// failed requests along the given curve, 1 when none failed
+func errorPenalty(summary models.BenchmarkSummary, curve string) float64 {
+	successRate := (100 - summary.ErrorRate) / 100
+	switch curve {
+	case models.ErrorPenaltyNone:
+		return 1
+	case models.ErrorPenaltySteep:
+		return math.Pow(successRate, 3)
+	default:
+		return successRate
+	}
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+
+	"llmbench/internal/models"
+)
+
+// SLAResult reports whether a provider/model met its provider's SLA
+type SLAResult struct {
+	Key        string
+	Violations []string
+}
+
+// Met reports whether the SLA was met
+func (r SLAResult) Met() bool {
+	return len(r.Violations) == 0
+}
+
+// CheckSLAs checks every summary against its provider's SLA, skipping
+// providers without one
+func (bs *BenchmarkService) CheckSLAs(summaries map[string]models.BenchmarkSummary) []SLAResult {
+	slas := make(map[string]*models.SLA)
+	for _, provider := range bs.providers {
+		if provider.SLA != nil {
+			slas[provider.Name] = provider.SLA
+		}
+	}
+
+	var results []SLAResult
+	for key, summary := range summaries {
+		sla, ok := slas[summary.Provider]
+		if !ok {
+			continue
+		}
+		results = append(results, SLAResult{Key: key, Violations: checkSLA(summary, *sla)})
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Key < results[j].Key })
+	return results
+}
+
+// checkSLA lists the ways a summary falls short of an SLA
+func checkSLA(summary models.BenchmarkSummary, sla models.SLA) []string {
+	var violations []string
+
+	if sla.P50Latency > 0 && summary.P50ResponseTime > sla.P50Latency {
+		violations = append(violations, fmt.Sprintf("p50 latency %v exceeds %v", summary.P50ResponseTime, sla.P50Latency))
+	}
+	if sla.P95Latency > 0 && summary.P95ResponseTime > sla.P95Latency {
+		violations = append(violations, fmt.Sprintf("p95 latency %v exceeds %v", summary.P95ResponseTime, sla.P95Latency))
+	}
+	if sla.P99Latency > 0 && summary.P99ResponseTime > sla.P99Latency {
+		violations = append(violations, fmt.Sprintf("p99 latency %v exceeds %v", summary.P99ResponseTime, sla.P99Latency))
+	}
+	if sla.MaxErrorRate != nil && summary.ErrorRate > *sla.MaxErrorRate {
+		violations = append(violations, fmt.Sprintf("error rate %.2f%% exceeds %.2f%%", summary.ErrorRate, *sla.MaxErrorRate))
+	}
+	if sla.MinThroughput > 0 && summary.IsStreaming && summary.AvgTokenThroughput < sla.MinThroughput {
+		violations = append(violations, fmt.Sprintf("throughput %.2f tokens/sec below %.2f", summary.AvgTokenThroughput, sla.MinThroughput))
+	}
+
+	return violations
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"llmbench/internal/models"
+
+	"github.com/openai/openai-go/option"
+)
+
+// defaultTokenTTL is how long a token is used for when its source doesn't
+// say how long it is valid
+const defaultTokenTTL = 5 * time.Minute
+
+// tokenExpiryMargin renews tokens this long before they expire, so that a
+// request doesn't leave with a token expiring in flight
+const tokenExpiryMargin = 30 * time.Second
+
+// tokenFetchTimeout bounds running the token command or calling the token
+// endpoint
+const tokenFetchTimeout = 30 * time.Second
+
+// tokenSource fetches the bearer token of a provider with token_auth and
+// caches it until it expires
+type tokenSource struct {
+	provider string
+	auth     models.TokenAuth
+	client   *http.Client // reaches the token endpoint
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// newTokenSource creates the token source of a provider, or returns nil
+// when it authenticates with static API keys. The token endpoint is reached
+// with the provider's proxy and TLS settings, as gateways that issue tokens
+// usually sit behind the same ones
+func newTokenSource(provider models.Provider) *tokenSource {
+	if provider.TokenAuth == nil {
+		return nil
+	}
+
+	client := newHTTPClient(provider)
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &tokenSource{provider: provider.Name, auth: *provider.TokenAuth, client: client}
+}
+
+// tokenSourceFor returns the provider's token source, shared by all its
+// models and keys so that a run fetches each token once, or nil when it
+// authenticates with static API keys
+func (bs *BenchmarkService) tokenSourceFor(provider models.Provider) *tokenSource {
+	if provider.TokenAuth == nil {
+		return nil
+	}
+
+	bs.tokenSourcesMu.Lock()
+	defer bs.tokenSourcesMu.Unlock()
+	source, ok := bs.tokenSources[provider.Name]
+	if !ok {
+		source = newTokenSource(provider)
+		bs.tokenSources[provider.Name] = source
+	}
+	return source
+}
+
+// Token returns the cached token, fetching a new one when there is none
+// yet or it is about to expire
+func (t *tokenSource) Token(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.token != "" && time.Now().Add(tokenExpiryMargin).Before(t.expiry) {
+		return t.token, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, tokenFetchTimeout)
+	defer cancel()
+
+	var token string
+	var ttl time.Duration
+	var err error
+	if t.auth.Command != "" {
+		token, err = t.runCommand(ctx)
+	} else {
+		token, ttl, err = t.fetchClientCredentials(ctx)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch bearer token for %s: %w", t.provider, err)
+	}
+
+	if ttl <= 0 {
+		ttl = t.auth.TTL
+	}
+	if ttl <= 0 {
+		ttl = defaultTokenTTL
+	}
+	t.token, t.expiry = token, time.Now().Add(ttl)
+	logf("%s: fetched a bearer token valid for %v", t.provider, ttl)
+	return token, nil
+}
+
+// invalidate drops the cached token, so that the next request fetches a
+// new one
+func (t *tokenSource) invalidate() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.token = ""
+}
+
+// runCommand runs the token command, whose trimmed output is the token
+func (t *tokenSource) runCommand(ctx context.Context) (string, error) {
+	output, err := exec.CommandContext(ctx, "sh", "-c", t.auth.Command).Output()
+	if err != nil {
+		return "", fmt.Errorf("token command failed: %w", err)
+	}
+	token := strings.TrimSpace(string(output))
+	if token == "" {
+		return "", fmt.Errorf("token command printed nothing")
+	}
+	return token, nil
+}
+
+// fetchClientCredentials requests a token from the OAuth2 endpoint with
+// the client credentials grant, returning it with its lifetime when given
+func (t *tokenSource) fetchClientCredentials(ctx context.Context) (string, time.Duration, error) {
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {t.auth.ClientID},
+		"client_secret": {t.auth.ClientSecret},
+	}
+	if t.auth.Scope != "" {
+		form.Set("scope", t.auth.Scope)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.auth.URL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", 0, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("token endpoint returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var tokenResponse struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"` // seconds
+	}
+	if err := json.Unmarshal(body, &tokenResponse); err != nil {
+		return "", 0, fmt.Errorf("invalid token response: %w", err)
+	}
+	if tokenResponse.AccessToken == "" {
+		return "", 0, fmt.Errorf("token response has no access_token")
+	}
+	return tokenResponse.AccessToken, time.Duration(tokenResponse.ExpiresIn) * time.Second, nil
+}
+
+// middleware sets the bearer token on every request, and drops it when the
+// provider rejects it so that the next request fetches a fresh one
+func (t *tokenSource) middleware() option.Middleware {
+	return func(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+		token, err := t.Token(req.Context())
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		res, err := next(req)
+		if err == nil && res.StatusCode == http.StatusUnauthorized {
+			t.invalidate()
+		}
+		return res, err
+	}
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+
+	"llmbench/internal/models"
+)
+
+// migrateResults upgrades results saved with an older layout to the current
+// one, one version at a time, and warns about files saved by a newer version
+// whose extra fields are lost on load
+func migrateResults(filename string, resultsFile *models.BenchmarkResultsFile) {
+	if resultsFile.SchemaVersion > models.ResultsSchemaVersion {
+		fmt.Fprintf(os.Stderr, "⚠️  WARNING: %s has schema version %d, newer than the supported %d; some fields may be missing\n",
+			filename, resultsFile.SchemaVersion, models.ResultsSchemaVersion)
+		return
+	}
+
+	if resultsFile.SchemaVersion < 1 {
+		migrateErrorKinds(resultsFile.Summaries, resultsFile.Results)
+		if resultsFile.Matrix != nil {
+			for i := range resultsFile.Matrix.Cells {
+				cell := &resultsFile.Matrix.Cells[i]
+				migrateErrorKinds(cell.Summaries, cell.Results)
+			}
+		}
+	}
+
+	resultsFile.SchemaVersion = models.ResultsSchemaVersion
+}
+
+// migrateErrorKinds classifies the failures of results saved before errors
+// were, as other, and counts them in their summary's error kinds
+func migrateErrorKinds(summaries map[string]models.BenchmarkSummary, results map[string][]models.BenchmarkResult) {
+	for _, providerResults := range results {
+		for i := range providerResults {
+			if !providerResults[i].Success && providerResults[i].ErrorKind == "" {
+				providerResults[i].ErrorKind = models.ErrorKindOther
+			}
+		}
+	}
+
+	// Summaries saved without results only know how many requests failed
+	for key, summary := range summaries {
+		if summary.FailedRequests > 0 && summary.ErrorKinds == nil {
+			summary.ErrorKinds = map[string]int{models.ErrorKindOther: summary.FailedRequests}
+			summaries[key] = summary
+		}
+	}
+}
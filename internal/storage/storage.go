@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"llmbench/internal/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SaveResults writes benchmark results to a YAML file atomically, so that a
+// crash mid-write never leaves a truncated file behind
+func SaveResults(filename string, resultsFile *models.BenchmarkResultsFile) error {
+	// Create directory if it doesn't exist
+	dir := filepath.Dir(filename)
+	if dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create directory %s: %w", dir, err)
+		}
+	}
+
+	yamlData, err := MarshalResults(resultsFile)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file in the same directory, then rename it into place
+	tmpFile, err := os.CreateTemp(dir, "."+filepath.Base(filename)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpName := tmpFile.Name()
+	defer os.Remove(tmpName)
+
+	if _, err := tmpFile.Write(yamlData); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to write results to file: %w", err)
+	}
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to sync results file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to close results file: %w", err)
+	}
+	if err := os.Chmod(tmpName, 0644); err != nil {
+		return fmt.Errorf("failed to set results file permissions: %w", err)
+	}
+
+	if err := os.Rename(tmpName, filename); err != nil {
+		return fmt.Errorf("failed to move results into place: %w", err)
+	}
+
+	return nil
+}
+
+// MarshalResults encodes benchmark results as YAML, in the layout written
+// by SaveResults
+func MarshalResults(resultsFile *models.BenchmarkResultsFile) ([]byte, error) {
+	resultsFile.SchemaVersion = models.ResultsSchemaVersion
+
+	yamlData, err := yaml.Marshal(resultsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal results to YAML: %w", err)
+	}
+	return yamlData, nil
+}
+
+// LoadResults loads benchmark results from a YAML file, migrating older
+// layouts to the current one
+func LoadResults(filename string) (*models.BenchmarkResultsFile, error) {
+	// Read the file
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	// Unmarshal YAML
+	var resultsFile models.BenchmarkResultsFile
+	if err := yaml.Unmarshal(data, &resultsFile); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	migrateResults(filename, &resultsFile)
+
+	return &resultsFile, nil
+}
+
+// SavedResults describes a results file found by ListResults
+type SavedResults struct {
+	Path      string
+	Timestamp time.Time
+	Metadata  models.BenchmarkMetadata
+}
+
+// ListResults finds the results files in a directory, newest first. YAML
+// files that aren't benchmark results, such as a config file, are skipped,
+// and a missing directory has no results
+func ListResults(dir string) ([]SavedResults, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read directory %s: %w", dir, err)
+	}
+
+	var saved []SavedResults
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		// Only the header is decoded, leaving the results out
+		var header struct {
+			Timestamp time.Time                `yaml:"timestamp"`
+			Metadata  models.BenchmarkMetadata `yaml:"metadata"`
+		}
+		if err := yaml.Unmarshal(data, &header); err != nil || header.Timestamp.IsZero() {
+			continue
+		}
+		saved = append(saved, SavedResults{Path: path, Timestamp: header.Timestamp, Metadata: header.Metadata})
+	}
+
+	sort.Slice(saved, func(i, j int) bool { return saved[i].Timestamp.After(saved[j].Timestamp) })
+	return saved, nil
+}
@@ -0,0 +1,73 @@
+package telemetry
+
+import "strconv"
+
+// The types below mirror the JSON encoding of the OTLP
+// ExportTraceServiceRequest message, limited to the fields llmbench sets
+
+type exportRequest struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource   resource     `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+type resource struct {
+	Attributes []attribute `json:"attributes"`
+}
+
+type scopeSpans struct {
+	Scope scope  `json:"scope"`
+	Spans []span `json:"spans"`
+}
+
+type scope struct {
+	Name string `json:"name"`
+}
+
+type span struct {
+	TraceID           string      `json:"traceId"`
+	SpanID            string      `json:"spanId"`
+	Name              string      `json:"name"`
+	Kind              int         `json:"kind"`
+	StartTimeUnixNano string      `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string      `json:"endTimeUnixNano"`
+	Attributes        []attribute `json:"attributes"`
+	Status            spanStatus  `json:"status"`
+}
+
+type spanStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type attribute struct {
+	Key   string         `json:"key"`
+	Value attributeValue `json:"value"`
+}
+
+type attributeValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"` // int64 values are strings in OTLP JSON
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+func stringAttribute(key, value string) attribute {
+	return attribute{Key: key, Value: attributeValue{StringValue: &value}}
+}
+
+func boolAttribute(key string, value bool) attribute {
+	return attribute{Key: key, Value: attributeValue{BoolValue: &value}}
+}
+
+func intAttribute(key string, value int) attribute {
+	s := strconv.Itoa(value)
+	return attribute{Key: key, Value: attributeValue{IntValue: &s}}
+}
+
+func doubleAttribute(key string, value float64) attribute {
+	return attribute{Key: key, Value: attributeValue{DoubleValue: &value}}
+}
@@ -0,0 +1,85 @@
+package telemetry
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"llmbench/internal/models"
+)
+
+// statsdPrefix namespaces every metric sent to StatsD
+const statsdPrefix = "llmbench."
+
+// StatsD pushes per-request timing and counter metrics to a StatsD agent
+// over UDP, tagged in the DogStatsD format. Sends are fire-and-forget: a
+// down agent loses the metrics but never slows or fails the benchmark. A nil
+// StatsD is a no-op.
+type StatsD struct {
+	conn net.Conn
+}
+
+// NewStatsD creates a StatsD client sending to the given host:port, or
+// returns nil when no address is configured or it can't be resolved
+func NewStatsD(addr string) *StatsD {
+	if addr == "" {
+		return nil
+	}
+
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  WARNING: statsd metrics disabled: %v\n", err)
+		return nil
+	}
+	return &StatsD{conn: conn}
+}
+
+// RecordRequest sends the latency, outcome and tokens of a single request
+func (s *StatsD) RecordRequest(result models.BenchmarkResult) {
+	if s == nil {
+		return
+	}
+
+	tags := fmt.Sprintf("|#provider:%s,model:%s", statsdTag(result.Provider), statsdTag(result.ModelName))
+	outcome := "success"
+	if !result.Success {
+		outcome = "failure"
+	}
+
+	metrics := []string{
+		timing("request.latency", result.ResponseTime) + tags,
+		statsdPrefix + "request." + outcome + ":1|c" + tags,
+	}
+	if result.Success {
+		metrics = append(metrics,
+			fmt.Sprintf("%stokens.input:%d|c%s", statsdPrefix, result.InputTokens, tags),
+			fmt.Sprintf("%stokens.output:%d|c%s", statsdPrefix, result.OutputTokens, tags),
+		)
+	}
+	if result.TimeToFirstToken > 0 {
+		metrics = append(metrics, timing("request.ttft", result.TimeToFirstToken)+tags)
+	}
+
+	// One datagram per request; a send error only means the agent is away
+	s.conn.Write([]byte(strings.Join(metrics, "\n")))
+}
+
+// Close closes the connection to the agent
+func (s *StatsD) Close() error {
+	if s == nil {
+		return nil
+	}
+	return s.conn.Close()
+}
+
+// timing formats a StatsD timing metric in milliseconds
+func timing(name string, d time.Duration) string {
+	return fmt.Sprintf("%s%s:%.3f|ms", statsdPrefix, name, float64(d)/float64(time.Millisecond))
+}
+
+// statsdTag replaces the characters that delimit DogStatsD tags
+func statsdTag(value string) string {
+	return strings.NewReplacer(",", "_", "|", "_", "#", "_").Replace(value)
+}
@@ -0,0 +1,161 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"llmbench/internal/models"
+)
+
+// OTLP span kind and status codes, see opentelemetry-proto trace.proto
+const (
+	spanKindClient  = 3
+	statusCodeOK    = 1
+	statusCodeError = 2
+)
+
+// defaultServiceName is reported as service.name when none is configured
+const defaultServiceName = "llmbench"
+
+// Tracer records one span per benchmark request and exports them to an
+// OTLP/HTTP endpoint using the JSON encoding. A nil Tracer is a no-op.
+type Tracer struct {
+	endpoint    string
+	headers     map[string]string
+	serviceName string
+	client      *http.Client
+
+	mu    sync.Mutex
+	spans []span
+}
+
+// NewTracer creates a tracer for the given configuration, or returns nil
+// when no OTLP endpoint is configured
+func NewTracer(config *models.TelemetryConfig) *Tracer {
+	if config == nil || config.OTLPEndpoint == "" {
+		return nil
+	}
+
+	serviceName := config.ServiceName
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+
+	return &Tracer{
+		endpoint:    strings.TrimSuffix(config.OTLPEndpoint, "/") + "/v1/traces",
+		headers:     config.Headers,
+		serviceName: serviceName,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// RecordRequest records a span covering a single benchmark request
+func (t *Tracer) RecordRequest(result models.BenchmarkResult) {
+	if t == nil {
+		return
+	}
+
+	attributes := []attribute{
+		stringAttribute("llmbench.provider", result.Provider),
+		stringAttribute("gen_ai.request.model", result.ModelName),
+		boolAttribute("llmbench.success", result.Success),
+		boolAttribute("llmbench.streaming", result.IsStreaming),
+		intAttribute("llmbench.tokens", result.TokensUsed),
+		intAttribute("llmbench.request_index", result.Index),
+	}
+	if result.IsStreaming {
+		attributes = append(attributes,
+			doubleAttribute("llmbench.ttft_ms", float64(result.TimeToFirstToken)/float64(time.Millisecond)),
+			doubleAttribute("llmbench.token_throughput", result.TokenThroughput),
+		)
+	}
+	if result.Turn > 0 {
+		attributes = append(attributes, intAttribute("llmbench.turn", result.Turn))
+	}
+
+	status := spanStatus{Code: statusCodeOK}
+	if !result.Success {
+		status = spanStatus{Code: statusCodeError, Message: result.Error}
+	}
+
+	s := span{
+		TraceID:           randomID(16),
+		SpanID:            randomID(8),
+		Name:              "chat.completion",
+		Kind:              spanKindClient,
+		StartTimeUnixNano: strconv.FormatInt(result.StartedAt.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(result.CompletedAt().UnixNano(), 10),
+		Attributes:        attributes,
+		Status:            status,
+	}
+
+	t.mu.Lock()
+	t.spans = append(t.spans, s)
+	t.mu.Unlock()
+}
+
+// Flush exports every recorded span and clears the buffer
+func (t *Tracer) Flush(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	spans := t.spans
+	t.spans = nil
+	t.mu.Unlock()
+
+	if len(spans) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(exportRequest{
+		ResourceSpans: []resourceSpans{{
+			Resource: resource{Attributes: []attribute{stringAttribute("service.name", t.serviceName)}},
+			ScopeSpans: []scopeSpans{{
+				Scope: scope{Name: "llmbench"},
+				Spans: spans,
+			}},
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode spans: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create export request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export spans: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to export spans: %s", resp.Status)
+	}
+
+	return nil
+}
+
+// randomID returns a random hex-encoded trace or span id of n bytes
+func randomID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -1,38 +1,43 @@
 package tui
 
 import (
-	"context"
 	"fmt"
-	"os"
+	"maps"
+	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
 	"llmbench/internal/charts"
 	"llmbench/internal/models"
 	"llmbench/internal/service"
+	"llmbench/internal/storage"
+	"llmbench/internal/units"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"gopkg.in/yaml.v3"
 )
 
 // App represents the TUI application
 type App struct {
 	benchmarkService *service.BenchmarkService
 	request          models.BenchmarkRequest
+	resultsDir       string
 }
 
-// NewApp creates a new TUI application
-func NewApp(benchmarkService *service.BenchmarkService, request models.BenchmarkRequest) *App {
+// NewApp creates a new TUI application; previous results are loaded from
+// resultsDir
+func NewApp(benchmarkService *service.BenchmarkService, request models.BenchmarkRequest, resultsDir string) *App {
 	return &App{
 		benchmarkService: benchmarkService,
 		request:          request,
+		resultsDir:       resultsDir,
 	}
 }
 
 // Run starts the TUI application
 func (a *App) Run() error {
-	model := newModel(a.benchmarkService, a.request)
+	model := newModel(a.benchmarkService, a.request, a.resultsDir)
 	p := tea.NewProgram(model, tea.WithAltScreen())
 	_, err := p.Run()
 	return err
@@ -48,6 +53,8 @@ const (
 	StateResults
 	StateSavePrompt
 	StateError
+	StateProviderSelect
+	StateHistory
 )
 
 // Model represents the TUI model
@@ -60,6 +67,10 @@ type Model struct {
 	menuCursor int
 	menuItems  []string
 
+	// Provider/model selection
+	selection       []SelectionItem
+	selectionCursor int
+
 	// Connection test
 	connectionResults map[string]error
 	connectionDone    bool
@@ -69,14 +80,25 @@ type Model struct {
 	benchmarkProgress map[string]BenchmarkProgress
 	benchmarkDone     bool
 	benchmarkError    error
+	benchmarkAborted  error // why the last run stopped part way, if it did
+
+	// Progress updates from the running benchmark
+	progress *progressTracker
 
-	// Benchmark channels for continuous progress updates
-	progressChan chan benchmarkProgressMsg
-	resultChan   chan tea.Msg
+	// Live preview of one streamed response, only when streaming
+	sample     *sampleBuffer
+	sampleText string
 
 	// Results
 	summaries map[string]models.BenchmarkSummary
 
+	// Previous results, and the metadata of the one being viewed if any
+	resultsDir     string
+	history        []storage.SavedResults
+	historyCursor  int
+	historyLoaded  bool
+	loadedMetadata *models.BenchmarkMetadata
+
 	// Chart functionality
 	chartGenerator *charts.ChartGenerator
 	currentChartTab int
@@ -97,6 +119,14 @@ type Model struct {
 type BenchmarkProgress struct {
 	Completed int
 	Total     int
+	ETA       string // estimated time left, as described by the ETA tracker
+}
+
+// SelectionItem is a provider/model pair that can be toggled in or out of a run
+type SelectionItem struct {
+	Provider string
+	Model    string
+	Selected bool
 }
 
 // ChartTab represents a chart tab with its metadata
@@ -111,16 +141,32 @@ type saveCompleteMsg struct {
 }
 
 // newModel creates a new model
-func newModel(benchmarkService *service.BenchmarkService, request models.BenchmarkRequest) Model {
+func newModel(benchmarkService *service.BenchmarkService, request models.BenchmarkRequest, resultsDir string) Model {
+	// Every configured provider/model starts out selected
+	var selection []SelectionItem
+	for _, provider := range benchmarkService.GetProviders() {
+		for _, model := range provider.Models {
+			selection = append(selection, SelectionItem{
+				Provider: provider.Name,
+				Model:    model,
+				Selected: true,
+			})
+		}
+	}
+
 	return Model{
 		state:            StateMenu,
 		benchmarkService: benchmarkService,
 		request:          request,
+		resultsDir:       resultsDir,
 		menuItems: []string{
 			"Test Connections",
+			"Select Providers",
 			"Run Benchmark",
+			"Load Previous Results",
 			"Quit",
 		},
+		selection:         selection,
 		benchmarkProgress: make(map[string]BenchmarkProgress),
 	}
 }
@@ -147,20 +193,26 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case benchmarkProgressMsg:
-		if m.benchmarkProgress == nil {
-			m.benchmarkProgress = make(map[string]BenchmarkProgress)
-		}
-		m.benchmarkProgress[msg.provider] = BenchmarkProgress{
-			Completed: msg.completed,
-			Total:     msg.total,
+		m.benchmarkProgress = msg.progress
+		if msg.done {
+			return m, nil
 		}
 		// Continue listening for more progress updates
-		return m, m.listenForProgress()
+		return m, listenForProgress(m.progress)
+
+	case sampleTextMsg:
+		m.sampleText = msg.text
+		if msg.done {
+			return m, nil
+		}
+		return m, listenForSample(m.sample)
 
 	case benchmarkCompleteMsg:
-		m.benchmarkResults = msg.results
+		m.benchmarkResults = msg.run.Results
+		m.benchmarkAborted = msg.aborted
 		m.benchmarkDone = true
-		m.summaries = m.benchmarkService.GenerateSummary(msg.results)
+		m.summaries = m.benchmarkService.GenerateSummary(msg.run)
+		m.loadedMetadata = nil
 		m.state = StateResults
 		// Initialize chart functionality
 		m.initializeCharts()
@@ -171,6 +223,31 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.state = StateError
 		return m, nil
 
+	case historyLoadedMsg:
+		if msg.err != nil {
+			m.benchmarkError = msg.err
+			m.state = StateError
+			return m, nil
+		}
+		m.history = msg.entries
+		m.historyCursor = 0
+		m.historyLoaded = true
+		return m, nil
+
+	case resultsLoadedMsg:
+		if msg.err != nil {
+			m.benchmarkError = msg.err
+			m.state = StateError
+			return m, nil
+		}
+		m.summaries = msg.file.Summaries
+		m.benchmarkResults = msg.file.Results
+		m.benchmarkAborted = nil
+		m.loadedMetadata = &msg.file.Metadata
+		m.state = StateResults
+		m.initializeCharts()
+		return m, nil
+
 	case saveCompleteMsg:
 		if msg.err != nil {
 			m.saveError = msg.err
@@ -198,6 +275,10 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.handleSavePromptKeys(msg)
 	case StateError:
 		return m.handleErrorKeys(msg)
+	case StateProviderSelect:
+		return m.handleProviderSelectKeys(msg)
+	case StateHistory:
+		return m.handleHistoryKeys(msg)
 	}
 	return m, nil
 }
@@ -221,18 +302,138 @@ func (m Model) handleMenuKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.state = StateConnectionTest
 			m.connectionDone = false
 			return m, m.testConnections()
-		case 1: // Run Benchmark
-			m.state = StateBenchmarkRunning
-			m.benchmarkDone = false
-			m.benchmarkProgress = make(map[string]BenchmarkProgress)
-			return m, m.runBenchmark()
-		case 2: // Quit
+		case 1: // Select Providers
+			m.state = StateProviderSelect
+			m.selectionCursor = 0
+		case 2: // Run Benchmark
+			return m.beginBenchmark()
+		case 3: // Load Previous Results
+			m.state = StateHistory
+			m.historyLoaded = false
+			return m, m.listResults()
+		case 4: // Quit
 			return m, tea.Quit
 		}
 	}
 	return m, nil
 }
 
+// beginBenchmark switches to the running screen and starts a benchmark of
+// the selected models, dropping the progress and results of any previous run
+func (m Model) beginBenchmark() (tea.Model, tea.Cmd) {
+	if len(m.selectedProviders()) == 0 {
+		m.benchmarkError = fmt.Errorf("no models selected; choose at least one in Select Providers")
+		m.state = StateError
+		return m, nil
+	}
+	m.state = StateBenchmarkRunning
+	m.benchmarkDone = false
+	m.benchmarkError = nil
+	m.benchmarkResults = nil
+	m.summaries = nil
+	m.benchmarkProgress = make(map[string]BenchmarkProgress)
+	modelCount := 0
+	for _, provider := range m.selectedProviders() {
+		modelCount += len(provider.Models)
+	}
+	// Each run gets its own tracker, so nothing left over from a previous
+	// run's channels can reach this one
+	m.progress = newProgressTracker(modelCount)
+
+	// Preview the first selected model's first response as it streams
+	m.sample = nil
+	m.sampleText = ""
+	if m.request.Stream && len(m.request.Conversation) == 0 {
+		first := m.selectedProviders()[0]
+		m.sample = newSampleBuffer(first.Name + "/" + first.Models[0])
+	}
+	return m, m.runBenchmark()
+}
+
+// handleProviderSelectKeys handles the provider/model selection checklist
+func (m Model) handleProviderSelectKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c", "q":
+		return m, tea.Quit
+	case "esc", "b", "enter":
+		m.state = StateMenu
+	case "up", "k":
+		if m.selectionCursor > 0 {
+			m.selectionCursor--
+		}
+	case "down", "j":
+		if m.selectionCursor < len(m.selection)-1 {
+			m.selectionCursor++
+		}
+	case " ", "x":
+		if len(m.selection) > 0 {
+			m.selection[m.selectionCursor].Selected = !m.selection[m.selectionCursor].Selected
+		}
+	case "a":
+		// Select all, or deselect all if everything is already selected
+		allSelected := true
+		for _, item := range m.selection {
+			if !item.Selected {
+				allSelected = false
+				break
+			}
+		}
+		for i := range m.selection {
+			m.selection[i].Selected = !allSelected
+		}
+	}
+	return m, nil
+}
+
+// selectedProviders returns the configured providers narrowed down to the
+// models selected in the checklist
+func (m Model) selectedProviders() []models.Provider {
+	selected := make(map[string]bool)
+	for _, item := range m.selection {
+		if item.Selected {
+			selected[item.Provider+"/"+item.Model] = true
+		}
+	}
+
+	var providers []models.Provider
+	for _, provider := range m.benchmarkService.GetProviders() {
+		var providerModels []string
+		for _, model := range provider.Models {
+			if selected[provider.Name+"/"+model] {
+				providerModels = append(providerModels, model)
+			}
+		}
+		if len(providerModels) > 0 {
+			provider.Models = providerModels
+			providers = append(providers, provider)
+		}
+	}
+	return providers
+}
+
+// handleHistoryKeys handles the list of previous results
+func (m Model) handleHistoryKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c", "q":
+		return m, tea.Quit
+	case "esc", "b":
+		m.state = StateMenu
+	case "up", "k":
+		if m.historyCursor > 0 {
+			m.historyCursor--
+		}
+	case "down", "j":
+		if m.historyCursor < len(m.history)-1 {
+			m.historyCursor++
+		}
+	case "enter", " ":
+		if m.historyLoaded && len(m.history) > 0 {
+			return m, m.loadResults(m.history[m.historyCursor].Path)
+		}
+	}
+	return m, nil
+}
+
 // handleConnectionTestKeys handles connection test screen
 func (m Model) handleConnectionTestKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
@@ -262,6 +463,10 @@ func (m Model) handleResultsKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, tea.Quit
 	case msg.String() == "esc" || msg.String() == "b":
 		m.state = StateMenu
+	case msg.String() == "r" && m.loadedMetadata == nil:
+		// Run again with the same settings and selection. Results loaded
+		// from a file weren't produced by them, so they can't be re-run
+		return m.beginBenchmark()
 	case msg.String() == "s":
 		// Start save process
 		m.state = StateSavePrompt
@@ -282,6 +487,15 @@ func (m Model) handleResultsKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// rerunHelp describes the re-run key, which results loaded from a file
+// don't have
+func (m Model) rerunHelp() string {
+	if m.loadedMetadata != nil {
+		return ""
+	}
+	return ", 'r' to re-run"
+}
+
 // handleSavePromptKeys handles save prompt screen
 func (m Model) handleSavePromptKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
@@ -338,6 +552,10 @@ func (m Model) View() string {
 		return m.renderSavePrompt()
 	case StateError:
 		return m.renderError()
+	case StateProviderSelect:
+		return m.renderProviderSelect()
+	case StateHistory:
+		return m.renderHistory()
 	}
 	return ""
 }
@@ -392,6 +610,16 @@ func (m Model) renderMenu() string {
 		}
 		b.WriteString(fmt.Sprintf("  • %s (%s)\n", provider.Name, modelsStr))
 	}
+	selectedCount := 0
+	for _, item := range m.selection {
+		if item.Selected {
+			selectedCount++
+		}
+	}
+	if selectedCount < len(m.selection) {
+		b.WriteString(infoStyle.Render(fmt.Sprintf("Selected for benchmark: %d/%d models", selectedCount, len(m.selection))))
+		b.WriteString("\n")
+	}
 	b.WriteString("\n")
 
 	b.WriteString("Choose an option:\n\n")
@@ -413,6 +641,98 @@ func (m Model) renderMenu() string {
 	return boxStyle.Render(b.String())
 }
 
+// renderProviderSelect renders the provider/model selection checklist
+func (m Model) renderProviderSelect() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Select Providers"))
+	b.WriteString("\n\n")
+
+	b.WriteString("Choose which provider/model combinations to benchmark:\n\n")
+
+	for i, item := range m.selection {
+		cursor := " "
+		if m.selectionCursor == i {
+			cursor = ">"
+		}
+		check := "[ ]"
+		if item.Selected {
+			check = "[x]"
+		}
+		line := fmt.Sprintf("%s %s %s/%s", cursor, check, item.Provider, item.Model)
+		if m.selectionCursor == i {
+			b.WriteString(selectedStyle.Render(line))
+		} else {
+			b.WriteString(normalStyle.Render(line))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(infoStyle.Render("Use ↑/↓ to navigate, Space to toggle, 'a' to toggle all, Enter or Esc to go back"))
+
+	return boxStyle.Render(b.String())
+}
+
+// renderHistory renders the list of previous results
+func (m Model) renderHistory() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Previous Results"))
+	b.WriteString("\n\n")
+
+	switch {
+	case !m.historyLoaded:
+		b.WriteString("⏳ Looking for saved results...")
+		return boxStyle.Render(b.String())
+	case len(m.history) == 0:
+		b.WriteString(fmt.Sprintf("No saved results found in %s\n\n", m.resultsDir))
+		b.WriteString(infoStyle.Render("Press 'b' or Esc to go back, q to quit"))
+		return boxStyle.Render(b.String())
+	}
+
+	b.WriteString(fmt.Sprintf("Saved results in %s:\n\n", m.resultsDir))
+	for i, entry := range m.history {
+		cursor := " "
+		if m.historyCursor == i {
+			cursor = ">"
+		}
+		line := fmt.Sprintf("%s %s  %s", cursor, entry.Timestamp.Local().Format("2006-01-02 15:04:05"), filepath.Base(entry.Path))
+		if m.historyCursor == i {
+			b.WriteString(selectedStyle.Render(line))
+		} else {
+			b.WriteString(normalStyle.Render(line))
+		}
+		b.WriteString("\n")
+		if message := historyMessage(entry.Metadata); message != "" {
+			b.WriteString(infoStyle.Render("    " + message))
+			b.WriteString("\n")
+		}
+	}
+
+	b.WriteString("\n")
+	b.WriteString(infoStyle.Render("Use ↑/↓ to navigate, Enter to open, 'b' or Esc to go back, q to quit"))
+
+	return boxStyle.Render(b.String())
+}
+
+// historyMessage describes what a saved run sent, shortened to one line
+func historyMessage(metadata models.BenchmarkMetadata) string {
+	const maxLength = 60
+
+	message := strings.Join(strings.Fields(metadata.Message), " ")
+	if message == "" && metadata.MessagesFile != "" {
+		message = "messages from " + metadata.MessagesFile
+	}
+	if runes := []rune(message); len(runes) > maxLength {
+		message = string(runes[:maxLength-1]) + "…"
+	}
+	if message == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s (%d requests)", message, metadata.Requests)
+}
+
 // initializeCharts sets up the chart generator and available chart tabs
 func (m *Model) initializeCharts() {
 	// Set up chart generator with appropriate dimensions
@@ -432,7 +752,7 @@ func (m *Model) initializeCharts() {
 	
 	m.chartGenerator = charts.NewChartGenerator(chartWidth, chartHeight)
 	
-	// Always initialize all three chart tabs for better user experience
+	// Always initialize all chart tabs for better user experience
 	// The chart generation will handle cases where data isn't available
 	m.chartTabs = []ChartTab{
 		{
@@ -450,6 +770,11 @@ func (m *Model) initializeCharts() {
 			Description: "Token throughput for streaming models",
 			ChartType:   "throughput",
 		},
+		{
+			Name:        "Throughput Over Time",
+			Description: "Per-request token throughput in completion order",
+			ChartType:   "throughput_over_time",
+		},
 	}
 	
 	// Start with the first tab
@@ -471,6 +796,8 @@ func (m Model) getCurrentChart() string {
 		return m.chartGenerator.GenerateTTFTChart(m.summaries)
 	case "throughput":
 		return m.chartGenerator.GenerateThroughputChart(m.summaries)
+	case "throughput_over_time":
+		return m.chartGenerator.GenerateThroughputOverTimeCharts(m.benchmarkResults)
 	default:
 		return "Unknown chart type"
 	}
@@ -511,140 +838,26 @@ func (m Model) saveResults() tea.Cmd {
 			filename += ".yaml"
 		}
 
-		// Create the saved results structure (same as in benchmark.go)
-		savedResults := struct {
-			Metadata struct {
-				Timestamp string `yaml:"timestamp"`
-				Version   string `yaml:"version"`
-			} `yaml:"metadata"`
-			Request   models.BenchmarkRequest             `yaml:"request"`
-			Results   map[string][]models.BenchmarkResult `yaml:"results"`
-			Summaries map[string]models.BenchmarkSummary  `yaml:"summaries"`
-		}{
-			Request:   m.request,
-			Results:   m.benchmarkResults,
+		// Use the same file structure as the CLI so that display can load it
+		resultsFile := &models.BenchmarkResultsFile{
+			Timestamp: time.Now(),
+			Metadata:  models.NewBenchmarkMetadata(m.request, m.benchmarkService.GetConfig()),
 			Summaries: m.summaries,
+			Results:   m.benchmarkResults,
 		}
-
-		// Set metadata
-		savedResults.Metadata.Timestamp = time.Now().Format(time.RFC3339)
-		savedResults.Metadata.Version = "1.0"
-
-		// Marshal to YAML
-		data, err := yaml.Marshal(savedResults)
-		if err != nil {
-			return saveCompleteMsg{err: fmt.Errorf("failed to marshal results: %w", err)}
+		// Results loaded from a file keep describing the run that produced them
+		if m.loadedMetadata != nil {
+			resultsFile.Metadata = *m.loadedMetadata
 		}
 
-		// Write to file
-		err = os.WriteFile(filename, data, 0644)
-		if err != nil {
-			return saveCompleteMsg{err: fmt.Errorf("failed to write file: %w", err)}
+		if err := storage.SaveResults(filename, resultsFile); err != nil {
+			return saveCompleteMsg{err: err}
 		}
 
 		return saveCompleteMsg{err: nil}
 	}
 }
 
-// testConnections tests connections to all providers
-func (m Model) testConnections() tea.Cmd {
-	return func() tea.Msg {
-		ctx := context.Background()
-		results := m.benchmarkService.TestConnections(ctx)
-		return connectionTestMsg{results: results}
-	}
-}
-
-// Global channels for progress updates (workaround for BubbleTea limitations)
-var (
-	globalProgressChan chan benchmarkProgressMsg
-	globalResultChan   chan tea.Msg
-)
-
-// runBenchmark runs the benchmark for all providers
-func (m Model) runBenchmark() tea.Cmd {
-	return tea.Batch(
-		m.startBenchmark(),
-		m.listenForUpdates(),
-	)
-}
-
-// startBenchmark starts the actual benchmark execution
-func (m Model) startBenchmark() tea.Cmd {
-	return func() tea.Msg {
-		ctx := context.Background()
-		
-		// Initialize global channels
-		globalProgressChan = make(chan benchmarkProgressMsg, 100)
-		globalResultChan = make(chan tea.Msg, 1)
-		
-		// Start benchmark in goroutine
-		go func() {
-			defer close(globalProgressChan)
-			defer close(globalResultChan)
-			
-			// Progress callback to send updates via global channel
-			progressCallback := func(provider string, completed, total int) {
-				select {
-				case globalProgressChan <- benchmarkProgressMsg{
-					provider:  provider,
-					completed: completed,
-					total:     total,
-				}:
-				default:
-					// Channel is full, skip this update
-				}
-			}
-
-			// Run the actual benchmark
-			results, err := m.benchmarkService.RunBenchmark(ctx, m.request, progressCallback)
-			if err != nil {
-				globalResultChan <- benchmarkErrorMsg{err: err}
-			} else {
-				globalResultChan <- benchmarkCompleteMsg{results: results}
-			}
-		}()
-		
-		return benchmarkStartMsg{}
-	}
-}
-
-// listenForUpdates continuously listens for progress updates and completion
-func (m Model) listenForUpdates() tea.Cmd {
-	return tea.Tick(time.Millisecond*100, func(t time.Time) tea.Msg {
-		if globalProgressChan == nil || globalResultChan == nil {
-			// Channels not ready yet, keep ticking
-			return tea.Tick(time.Millisecond*100, func(t time.Time) tea.Msg {
-				return m.listenForUpdates()()
-			})()
-		}
-		
-		// Non-blocking check for messages
-		select {
-		case progress, ok := <-globalProgressChan:
-			if ok {
-				return progress
-			}
-		case result, ok := <-globalResultChan:
-			if ok {
-				return result
-			}
-		default:
-			// No messages available, continue ticking
-		}
-		
-		// Continue listening by returning another tick
-		return tea.Tick(time.Millisecond*100, func(t time.Time) tea.Msg {
-			return m.listenForUpdates()()
-		})()
-	})
-}
-
-// listenForProgress continues listening for progress updates
-func (m Model) listenForProgress() tea.Cmd {
-	return m.listenForUpdates()
-}
-
 // renderConnectionTest renders the connection test screen
 func (m Model) renderConnectionTest() string {
 	var b strings.Builder
@@ -659,7 +872,8 @@ func (m Model) renderConnectionTest() string {
 		b.WriteString("Connection test results:\n\n")
 
 		successCount := 0
-		for provider, err := range m.connectionResults {
+		for _, provider := range slices.Sorted(maps.Keys(m.connectionResults)) {
+			err := m.connectionResults[provider]
 			if err != nil {
 				b.WriteString(errorStyle.Render(fmt.Sprintf("❌ %s: %v", provider, err)))
 			} else {
@@ -694,26 +908,18 @@ func (m Model) renderBenchmark() string {
 	if !m.benchmarkDone {
 		b.WriteString("Benchmark in progress...\n\n")
 
-		// Get provider names and sort them alphabetically for consistent display
-		var providers []string
-		for provider := range m.benchmarkProgress {
-			providers = append(providers, provider)
-		}
-		
-		// Sort providers alphabetically
-		for i := 0; i < len(providers); i++ {
-			for j := i + 1; j < len(providers); j++ {
-				if providers[i] > providers[j] {
-					providers[i], providers[j] = providers[j], providers[i]
-				}
-			}
-		}
+		// Sort provider names alphabetically for consistent display
+		providers := slices.Sorted(maps.Keys(m.benchmarkProgress))
 
 		// Display progress bars in sorted order
 		for _, provider := range providers {
 			progress := m.benchmarkProgress[provider]
 			percentage := float64(progress.Completed) / float64(progress.Total) * 100
-			b.WriteString(fmt.Sprintf("%s: %d/%d (%.1f%%)\n", provider, progress.Completed, progress.Total, percentage))
+			b.WriteString(fmt.Sprintf("%s: %d/%d (%.1f%%)", provider, progress.Completed, progress.Total, percentage))
+			if progress.Completed < progress.Total {
+				b.WriteString(" " + infoStyle.Render(progress.ETA))
+			}
+			b.WriteString("\n")
 
 			// Simple progress bar
 			barWidth := 30
@@ -722,18 +928,52 @@ func (m Model) renderBenchmark() string {
 			b.WriteString(fmt.Sprintf("[%s]\n\n", bar))
 		}
 
+		if m.sample != nil {
+			b.WriteString(m.renderSample())
+		}
+
 		b.WriteString(infoStyle.Render("Press Ctrl+C to cancel"))
 	}
 
 	return boxStyle.Render(b.String())
 }
 
+// renderSample renders the tail of the sample response streamed so far
+func (m Model) renderSample() string {
+	const (
+		sampleWidth = 60
+		sampleLines = 6
+	)
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Sample response (%s):\n", m.sample.key))
+
+	if m.sampleText == "" {
+		b.WriteString(infoStyle.Render("Waiting for the first tokens..."))
+		b.WriteString("\n\n")
+		return b.String()
+	}
+
+	// Only the last few wrapped lines fit, as the response keeps growing
+	lines := strings.Split(lipgloss.NewStyle().Width(sampleWidth).Render(m.sampleText), "\n")
+	if len(lines) > sampleLines {
+		lines = lines[len(lines)-sampleLines:]
+	}
+	b.WriteString(strings.Join(lines, "\n"))
+	b.WriteString("\n\n")
+	return b.String()
+}
+
 // renderResults renders the results screen with chart-based visualization
 func (m Model) renderResults() string {
 	var b strings.Builder
 
 	b.WriteString(titleStyle.Render("Benchmark Results"))
 	b.WriteString("\n\n")
+	if m.benchmarkAborted != nil {
+		b.WriteString(errorStyle.Render(fmt.Sprintf("⚠️  Benchmark aborted: %v", m.benchmarkAborted)))
+		b.WriteString("\n\n")
+	}
 
 	// Render chart tabs if available
 	if len(m.chartTabs) > 0 {
@@ -759,27 +999,32 @@ func (m Model) renderResults() string {
 		
 		// Navigation instructions
 		if len(m.chartTabs) > 1 {
-			b.WriteString(infoStyle.Render("Use ←/→ or h/l to switch charts, 's' to save, 'b' or Esc to go back, q to quit"))
+			b.WriteString(infoStyle.Render(fmt.Sprintf("Use ←/→ or h/l to switch charts, 's' to save%s, 'b' or Esc to go back, q to quit", m.rerunHelp())))
 		} else {
-			b.WriteString(infoStyle.Render("Press 's' to save results, 'b' or Esc to go back, q to quit"))
+			b.WriteString(infoStyle.Render(fmt.Sprintf("Press 's' to save results%s, 'b' or Esc to go back, q to quit", m.rerunHelp())))
 		}
 	} else {
 		// Fallback to text-based results if no charts available
-		for provider, summary := range m.summaries {
+		for _, provider := range slices.Sorted(maps.Keys(m.summaries)) {
+			summary := m.summaries[provider]
 			b.WriteString(fmt.Sprintf("📊 %s\n", strings.ToUpper(provider)))
 			b.WriteString(strings.Repeat("-", 30) + "\n")
-			b.WriteString(fmt.Sprintf("Total Requests:     %d\n", summary.TotalRequests))
+			if summary.SkippedRequests > 0 {
+				b.WriteString(fmt.Sprintf("Total Requests:     %d of %d planned (%d skipped)\n", summary.TotalRequests, summary.PlannedRequests, summary.SkippedRequests))
+			} else {
+				b.WriteString(fmt.Sprintf("Total Requests:     %d\n", summary.TotalRequests))
+			}
 			b.WriteString(fmt.Sprintf("Successful:         %d\n", summary.SuccessfulReqs))
 			b.WriteString(fmt.Sprintf("Failed:             %d\n", summary.FailedRequests))
 			b.WriteString(fmt.Sprintf("Error Rate:         %.2f%%\n", summary.ErrorRate))
-			b.WriteString(fmt.Sprintf("Avg Response Time:  %v\n", summary.AvgResponseTime))
-			b.WriteString(fmt.Sprintf("Min Response Time:  %v\n", summary.MinResponseTime))
-			b.WriteString(fmt.Sprintf("Max Response Time:  %v\n", summary.MaxResponseTime))
-			b.WriteString(fmt.Sprintf("Total Tokens:       %d\n", summary.TotalTokens))
+			b.WriteString(fmt.Sprintf("Avg Response Time:  %s\n", units.Duration(summary.AvgResponseTime)))
+			b.WriteString(fmt.Sprintf("Min Response Time:  %s\n", units.Duration(summary.MinResponseTime)))
+			b.WriteString(fmt.Sprintf("Max Response Time:  %s\n", units.Duration(summary.MaxResponseTime)))
+			b.WriteString(fmt.Sprintf("Total Tokens:       %s\n", units.Int(summary.TotalTokens)))
 			b.WriteString("\n")
 		}
 
-		b.WriteString(infoStyle.Render("Press 's' to save results, 'b' or Esc to go back, q to quit"))
+		b.WriteString(infoStyle.Render(fmt.Sprintf("Press 's' to save results%s, 'b' or Esc to go back, q to quit", m.rerunHelp())))
 	}
 
 	return boxStyle.Render(b.String())
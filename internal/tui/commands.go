@@ -1,7 +1,16 @@
 package tui
 
 import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
 	"llmbench/internal/models"
+	"llmbench/internal/service"
+	"llmbench/internal/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
 )
 
 // Messages for the TUI
@@ -11,22 +20,239 @@ type connectionTestMsg struct {
 	results map[string]error
 }
 
-// benchmarkStartMsg is sent when benchmark starts
-type benchmarkStartMsg struct{}
-
-// benchmarkProgressMsg is sent to update benchmark progress
+// benchmarkProgressMsg carries the latest progress of every provider/model;
+// done is set once the benchmark has stopped reporting progress
 type benchmarkProgressMsg struct {
-	provider  string
-	completed int
-	total     int
+	progress map[string]BenchmarkProgress
+	done     bool
 }
 
-// benchmarkCompleteMsg is sent when benchmark completes
+// sampleTextMsg carries the text streamed so far by the sample request;
+// done is set once the benchmark is over
+type sampleTextMsg struct {
+	text string
+	done bool
+}
+
+// benchmarkCompleteMsg is sent when benchmark completes; aborted is set
+// when it stopped part way, leaving the run with what completed
 type benchmarkCompleteMsg struct {
-	results map[string][]models.BenchmarkResult
+	run     *service.Run
+	aborted error
 }
 
 // benchmarkErrorMsg is sent when benchmark fails
 type benchmarkErrorMsg struct {
 	err error
 }
+
+// historyLoadedMsg is sent once the previous results have been listed
+type historyLoadedMsg struct {
+	entries []storage.SavedResults
+	err     error
+}
+
+// resultsLoadedMsg is sent when previous results have been loaded
+type resultsLoadedMsg struct {
+	file *models.BenchmarkResultsFile
+	err  error
+}
+
+// Commands for the TUI
+
+// testConnections tests connections to all providers
+func (m Model) testConnections() tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		results := m.benchmarkService.TestConnections(ctx)
+		return connectionTestMsg{results: results}
+	}
+}
+
+// runBenchmark runs the benchmark for all providers while streaming
+// progress updates back to the model through its progress tracker
+func (m Model) runBenchmark() tea.Cmd {
+	cmds := []tea.Cmd{
+		m.startBenchmark(),
+		listenForProgress(m.progress),
+	}
+	if m.sample != nil {
+		cmds = append(cmds, listenForSample(m.sample))
+	}
+	return tea.Batch(cmds...)
+}
+
+// startBenchmark runs the benchmark and reports its outcome once done
+func (m Model) startBenchmark() tea.Cmd {
+	tracker := m.progress
+	sample := m.sample
+	return func() tea.Msg {
+		defer tracker.close()
+
+		if sample != nil {
+			m.benchmarkService.SetSampleSink(sample.write)
+			defer func() {
+				m.benchmarkService.SetSampleSink(nil)
+				sample.close()
+			}()
+		}
+
+		ctx := context.Background()
+
+		run, err := m.benchmarkService.RunBenchmarkFor(ctx, m.selectedProviders(), m.request, tracker.update)
+		if run == nil {
+			return benchmarkErrorMsg{err: err}
+		}
+		return benchmarkCompleteMsg{run: run, aborted: err}
+	}
+}
+
+// listResults lists the results saved in the results directory
+func (m Model) listResults() tea.Cmd {
+	dir := m.resultsDir
+	return func() tea.Msg {
+		entries, err := storage.ListResults(dir)
+		return historyLoadedMsg{entries: entries, err: err}
+	}
+}
+
+// loadResults loads previous results to view them again
+func (m Model) loadResults(filename string) tea.Cmd {
+	return func() tea.Msg {
+		file, err := storage.LoadResults(filename)
+		if err != nil {
+			return resultsLoadedMsg{err: err}
+		}
+		if file.Matrix != nil {
+			return resultsLoadedMsg{err: fmt.Errorf("%s holds matrix results, which can only be viewed with the display command", filename)}
+		}
+		return resultsLoadedMsg{file: file}
+	}
+}
+
+// listenForProgress waits for progress updates and delivers the latest
+// state of every provider/model, including the final one once the
+// benchmark stops reporting
+func listenForProgress(tracker *progressTracker) tea.Cmd {
+	return func() tea.Msg {
+		_, ok := <-tracker.notify
+		return benchmarkProgressMsg{
+			progress: tracker.snapshot(),
+			done:     !ok,
+		}
+	}
+}
+
+// progressTracker coalesces progress updates, keeping only the latest one
+// per provider/model, so no update is lost however fast they arrive
+type progressTracker struct {
+	mu     sync.Mutex
+	latest map[string]BenchmarkProgress
+	eta    *service.ETATracker
+	notify chan struct{} // signalled whenever latest changes
+}
+
+// newProgressTracker creates a tracker whose notification buffer scales
+// with the number of provider/models reporting progress concurrently
+func newProgressTracker(modelCount int) *progressTracker {
+	return &progressTracker{
+		latest: make(map[string]BenchmarkProgress),
+		eta:    service.NewETATracker(),
+		notify: make(chan struct{}, max(modelCount, 1)),
+	}
+}
+
+// update records the progress of a provider/model and wakes up the listener
+func (t *progressTracker) update(provider string, completed, total int) {
+	t.eta.Record(provider, completed)
+
+	t.mu.Lock()
+	t.latest[provider] = BenchmarkProgress{
+		Completed: completed,
+		Total:     total,
+		ETA:       t.eta.Describe(provider, total),
+	}
+	t.mu.Unlock()
+
+	select {
+	case t.notify <- struct{}{}:
+	default:
+		// A notification is already pending and will pick this update up
+	}
+}
+
+// snapshot returns a copy of the latest progress of every provider/model
+func (t *progressTracker) snapshot() map[string]BenchmarkProgress {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	progress := make(map[string]BenchmarkProgress, len(t.latest))
+	for provider, p := range t.latest {
+		progress[provider] = p
+	}
+	return progress
+}
+
+// close stops the listener once the benchmark is over
+func (t *progressTracker) close() {
+	close(t.notify)
+}
+
+// listenForSample waits for more of the sample response and delivers the
+// text streamed so far
+func listenForSample(sample *sampleBuffer) tea.Cmd {
+	return func() tea.Msg {
+		_, ok := <-sample.notify
+		return sampleTextMsg{
+			text: sample.text(),
+			done: !ok,
+		}
+	}
+}
+
+// sampleBuffer accumulates the response streamed by a single provider/model's
+// first request, so one sample response can be previewed live
+type sampleBuffer struct {
+	key    string // provider/model whose response is sampled
+	mu     sync.Mutex
+	buf    strings.Builder
+	notify chan struct{} // signalled whenever the text grows
+}
+
+// newSampleBuffer creates a buffer sampling the given provider/model
+func newSampleBuffer(key string) *sampleBuffer {
+	return &sampleBuffer{
+		key:    key,
+		notify: make(chan struct{}, 1),
+	}
+}
+
+// write appends a chunk streamed by a provider/model, ignoring the ones
+// that are not being sampled
+func (s *sampleBuffer) write(key, chunk string) {
+	if key != s.key {
+		return
+	}
+
+	s.mu.Lock()
+	s.buf.WriteString(chunk)
+	s.mu.Unlock()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+		// A notification is already pending and will pick this chunk up
+	}
+}
+
+// text returns the text streamed so far
+func (s *sampleBuffer) text() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.String()
+}
+
+// close stops the listener once the benchmark is over
+func (s *sampleBuffer) close() {
+	close(s.notify)
+}
@@ -0,0 +1,79 @@
+package units
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Duration units
+const (
+	Millis  = "ms"
+	Seconds = "s"
+	Auto    = "auto"
+)
+
+// unit is the unit durations are formatted in
+var unit = Auto
+
+// Set selects the unit durations are formatted in: ms, s or auto, which
+// uses milliseconds below a second and seconds above
+func Set(u string) error {
+	switch u {
+	case Millis, Seconds, Auto:
+		unit = u
+		return nil
+	default:
+		return fmt.Errorf("invalid units %q: must be ms, s or auto", u)
+	}
+}
+
+// Duration formats a duration in the selected unit with a fixed precision,
+// e.g. 1,234.6ms or 1.235s
+func Duration(d time.Duration) string {
+	if unit == Millis || (unit == Auto && d < time.Second) {
+		return Float(float64(d)/float64(time.Millisecond), 1) + "ms"
+	}
+	return Float(d.Seconds(), 3) + "s"
+}
+
+// Int formats a count with thousands separators, e.g. 1,234,567
+func Int(n int) string {
+	digits := strconv.Itoa(n)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	return sign + group(digits)
+}
+
+// Float formats a number with the given decimals and thousands separators
+func Float(f float64, decimals int) string {
+	s := strconv.FormatFloat(f, 'f', decimals, 64)
+	sign := ""
+	if len(s) > 0 && s[0] == '-' {
+		sign, s = "-", s[1:]
+	}
+
+	integer, fraction := s, ""
+	if i := len(s) - decimals - 1; decimals > 0 && i >= 0 {
+		integer, fraction = s[:i], s[i:]
+	}
+	return sign + group(integer) + fraction
+}
+
+// group inserts a comma between every group of three digits
+func group(digits string) string {
+	if len(digits) <= 3 {
+		return digits
+	}
+	head := len(digits) % 3
+	if head == 0 {
+		head = 3
+	}
+	grouped := digits[:head]
+	for i := head; i < len(digits); i += 3 {
+		grouped += "," + digits[i:i+3]
+	}
+	return grouped
+}